@@ -0,0 +1,115 @@
+package radix
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrEmptyLabel is returned when validating a batch that has an empty label.
+	ErrEmptyLabel = errors.New("radix: empty label")
+	// ErrNilValue is returned when validating a batch that has a nil value.
+	ErrNilValue = errors.New("radix: nil value")
+	// ErrConflict is returned when validating a batch that has a repeated label.
+	ErrConflict = errors.New("radix: conflicting label")
+)
+
+// BatchError is the error returned when a batch fails validation.
+type BatchError struct {
+	Index int    // position of the label in the batch
+	Label string // label that failed validation
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%v at %d (%q)", e.Err, e.Index, e.Label)
+}
+
+// Unwrap returns the reason why the batch failed validation.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// MapIterator returns an iterator over the labels and values of m.
+func MapIterator(m map[string]interface{}) Iterator {
+	labels := make([]string, 0, len(m))
+	for label := range m {
+		labels = append(labels, label)
+	}
+	i := 0
+	return func() (string, interface{}, bool) {
+		if i == len(labels) {
+			return "", nil, false
+		}
+		i++
+		return labels[i-1], m[labels[i-1]], true
+	}
+}
+
+// AddAll adds the labels and values returned by it while holding the tree's lock,
+// so readers see either none or all of them.
+//
+// If validate is true, the whole batch is validated before the tree is changed,
+// and a *BatchError is returned if any label is empty, any value is nil or
+// any label is repeated, which would make the batch depend on its order.
+// Otherwise, invalid labels and values are skipped, just like in (*Tree).Add.
+//
+// Labels that already hold values in the tree are updated, just like in (*Tree).Add.
+func (tr *Tree) AddAll(it Iterator, validate bool) error {
+	type entry struct {
+		label string
+		v     interface{}
+	}
+	var batch []entry
+	for {
+		label, v, ok := it()
+		if !ok {
+			break
+		}
+		batch = append(batch, entry{label, v})
+	}
+	if tr.safe {
+		defer tr.mu.Unlock()
+		tr.mu.Lock()
+	}
+	if validate {
+		seen := make(map[string]bool, len(batch))
+		for i, e := range batch {
+			var err error
+			switch {
+			case e.label == "":
+				err = ErrEmptyLabel
+			case e.v == nil:
+				err = ErrNilValue
+			default:
+				key := tr.key(e.label)
+				if !seen[key] {
+					seen[key] = true
+					continue
+				}
+				err = ErrConflict
+			}
+			return &BatchError{Index: i, Label: e.label, Err: err}
+		}
+	}
+	for _, e := range batch {
+		if e.label != "" && e.v != nil {
+			tr.add(e.label, e.v, 0)
+		}
+	}
+	return nil
+}
+
+// DelAll deletes the nodes of labels while holding the tree's lock,
+// so readers see either none or all of them deleted.
+func (tr *Tree) DelAll(labels []string) {
+	if tr.safe {
+		defer tr.mu.Unlock()
+		tr.mu.Lock()
+	}
+	for _, label := range labels {
+		if label != "" {
+			tr.del(label)
+		}
+	}
+}
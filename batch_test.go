@@ -0,0 +1,97 @@
+package radix_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestAddAll(t *testing.T) {
+	testCases := []struct {
+		flags    int
+		labels   []string
+		values   []interface{}
+		validate bool
+		err      error
+		index    int
+		length   int
+	}{
+		{0, []string{"romane", "romanus", "romulus"}, []interface{}{1, 2, 3}, true, nil, 0, 8},
+		{0, []string{"romane", "", "romulus"}, []interface{}{1, 2, 3}, false, nil, 0, 6},
+		{0, []string{"romane", "", "romulus"}, []interface{}{1, 2, 3}, true, ErrEmptyLabel, 1, 2},
+		{0, []string{"romane", "romanus", "romulus"}, []interface{}{1, nil, 3}, true, ErrNilValue, 1, 2},
+		{0, []string{"romane", "romanus", "romane"}, []interface{}{1, 2, 3}, true, ErrConflict, 2, 2},
+		{0, []string{"romanus", "rubens"}, []interface{}{1, 2}, true, nil, 0, 4}, // existing labels are updated
+		{Tfold, []string{"romane", "ROMANE"}, []interface{}{1, 2}, true, ErrConflict, 1, 2},
+		{Tbinary, []string{"romane", "romane"}, []interface{}{1, 2}, true, ErrConflict, 1, 2},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(tc.flags | Tsafe)
+			tr.Add("rubens", 0)
+			i := 0
+			err := tr.AddAll(func() (string, interface{}, bool) {
+				if i == len(tc.labels) {
+					return "", nil, false
+				}
+				i++
+				return tc.labels[i-1], tc.values[i-1], true
+			}, tc.validate)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("want %v, got %v", tc.err, err)
+			}
+			if err != nil {
+				var berr *BatchError
+				if !errors.As(err, &berr) || berr.Index != tc.index {
+					t.Errorf("want error at %d, got %v", tc.index, err)
+				}
+			}
+			if want, got := tc.length, tr.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+		})
+	}
+
+	// Expired labels are also just updated.
+	tr := New(0)
+	tr.AddWithTTL("rubens", 0, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if err := tr.AddAll(MapIterator(map[string]interface{}{"rubens": 1}), true); err != nil {
+		t.Fatalf("want no error, got %v", err)
+	}
+	if n, _ := tr.Get("rubens"); n == nil || n.Value != 1 {
+		t.Errorf("want %q to be updated, got %v", "rubens", n)
+	}
+}
+
+func TestDelAll(t *testing.T) {
+	tr := New(Tsafe)
+	tr.AddAll(MapIterator(map[string]interface{}{
+		"romane":  1,
+		"romanus": 2,
+		"romulus": 3,
+		"rubens":  4,
+	}), true)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			// Either both or none are deleted.
+			n1, _ := tr.Get("romane")
+			n2, _ := tr.Get("rubens")
+			if (n1 == nil) != (n2 == nil) {
+				t.Error("want labels to be deleted at once")
+				return
+			}
+		}
+	}()
+	tr.DelAll([]string{"romane", "rubens", "missing"})
+	wg.Wait()
+	if want, got := 4, tr.Len(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
@@ -1,7 +1,13 @@
 package radix_test
 
 import (
+	"encoding/binary"
+	"math/rand"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
 	. "github.com/gbrlsnchs/radix"
@@ -20,5 +26,505 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+// githubAPI holds routes of the GitHub API, using '@' as placeholder and '/' as delimiter.
+var githubAPI = []string{
+	"/authorizations",
+	"/authorizations/@id",
+	"/applications/@client_id/tokens",
+	"/applications/@client_id/tokens/@access_token",
+	"/events",
+	"/repos/@owner/@repo/events",
+	"/networks/@owner/@repo/events",
+	"/orgs/@org/events",
+	"/users/@user/received_events",
+	"/users/@user/received_events/public",
+	"/users/@user/events",
+	"/users/@user/events/public",
+	"/users/@user/events/orgs/@org",
+	"/feeds",
+	"/notifications",
+	"/repos/@owner/@repo/notifications",
+	"/notifications/threads/@id",
+	"/notifications/threads/@id/subscription",
+	"/repos/@owner/@repo/stargazers",
+	"/users/@user/starred",
+	"/user/starred",
+	"/user/starred/@owner/@repo",
+	"/repos/@owner/@repo/subscribers",
+	"/users/@user/subscriptions",
+	"/user/subscriptions",
+	"/repos/@owner/@repo/subscription",
+	"/user/subscriptions/@owner/@repo",
+	"/users/@user/gists",
+	"/gists",
+	"/gists/public",
+	"/gists/starred",
+	"/gists/@id",
+	"/gists/@id/star",
+	"/gists/@id/forks",
+	"/repos/@owner/@repo/git/blobs/@sha",
+	"/repos/@owner/@repo/git/commits/@sha",
+	"/repos/@owner/@repo/git/refs",
+	"/repos/@owner/@repo/git/tags/@sha",
+	"/repos/@owner/@repo/git/trees/@sha",
+	"/issues",
+	"/user/issues",
+	"/orgs/@org/issues",
+	"/repos/@owner/@repo/issues",
+	"/repos/@owner/@repo/issues/@number",
+	"/repos/@owner/@repo/issues/@number/comments",
+	"/repos/@owner/@repo/issues/@number/events",
+	"/repos/@owner/@repo/issues/@number/labels",
+	"/repos/@owner/@repo/assignees",
+	"/repos/@owner/@repo/assignees/@assignee",
+	"/repos/@owner/@repo/labels",
+	"/repos/@owner/@repo/labels/@name",
+	"/repos/@owner/@repo/milestones",
+	"/repos/@owner/@repo/milestones/@number",
+	"/repos/@owner/@repo/milestones/@number/labels",
+	"/emojis",
+	"/gitignore/templates",
+	"/gitignore/templates/@name",
+	"/markdown",
+	"/markdown/raw",
+	"/meta",
+	"/rate_limit",
+	"/users/@user/orgs",
+	"/user/orgs",
+	"/orgs/@org",
+	"/orgs/@org/members",
+	"/orgs/@org/members/@user",
+	"/orgs/@org/public_members",
+	"/orgs/@org/public_members/@user",
+	"/orgs/@org/teams",
+	"/teams/@id",
+	"/teams/@id/members",
+	"/teams/@id/members/@user",
+	"/teams/@id/repos",
+	"/teams/@id/repos/@owner/@repo",
+	"/user/teams",
+	"/repos/@owner/@repo/pulls",
+	"/repos/@owner/@repo/pulls/@number",
+	"/repos/@owner/@repo/pulls/@number/commits",
+	"/repos/@owner/@repo/pulls/@number/files",
+	"/repos/@owner/@repo/pulls/@number/merge",
+	"/repos/@owner/@repo/pulls/@number/comments",
+	"/user/repos",
+	"/users/@user/repos",
+	"/orgs/@org/repos",
+	"/repositories",
+	"/repos/@owner/@repo",
+	"/repos/@owner/@repo/contributors",
+	"/repos/@owner/@repo/languages",
+	"/repos/@owner/@repo/teams",
+	"/repos/@owner/@repo/tags",
+	"/repos/@owner/@repo/branches",
+	"/repos/@owner/@repo/branches/@branch",
+	"/repos/@owner/@repo/collaborators",
+	"/repos/@owner/@repo/collaborators/@user",
+	"/repos/@owner/@repo/comments",
+	"/repos/@owner/@repo/commits",
+	"/repos/@owner/@repo/commits/@sha",
+	"/repos/@owner/@repo/readme",
+	"/repos/@owner/@repo/keys",
+	"/repos/@owner/@repo/keys/@id",
+	"/repos/@owner/@repo/downloads",
+	"/repos/@owner/@repo/downloads/@id",
+	"/repos/@owner/@repo/forks",
+	"/repos/@owner/@repo/hooks",
+	"/repos/@owner/@repo/hooks/@id",
+	"/repos/@owner/@repo/releases",
+	"/repos/@owner/@repo/releases/@id",
+	"/repos/@owner/@repo/releases/@id/assets",
+	"/repos/@owner/@repo/stats/contributors",
+	"/repos/@owner/@repo/stats/commit_activity",
+	"/repos/@owner/@repo/stats/code_frequency",
+	"/repos/@owner/@repo/stats/participation",
+	"/repos/@owner/@repo/stats/punch_card",
+	"/repos/@owner/@repo/statuses/@ref",
+	"/search/repositories",
+	"/search/code",
+	"/search/issues",
+	"/search/users",
+	"/legacy/issues/search/@owner/@repository/@state/@keyword",
+	"/legacy/repos/search/@keyword",
+	"/legacy/user/search/@keyword",
+	"/legacy/user/email/@email",
+	"/users/@user",
+	"/user",
+	"/users",
+	"/user/emails",
+	"/users/@user/followers",
+	"/user/followers",
+	"/users/@user/following",
+	"/user/following",
+	"/user/following/@user",
+	"/users/@user/following/@target_user",
+	"/users/@user/keys",
+	"/user/keys",
+	"/user/keys/@id",
+}
+
+type corpus struct {
+	name   string
+	labels []string
+}
+
+var (
+	corporaOnce sync.Once
+	corpora     []corpus
+)
+
+// benchCorpora generates the corpora used by benchmarks. They're generated
+// with a fixed seed, so results are comparable between runs.
+func benchCorpora() []corpus {
+	corporaOnce.Do(func() {
+		rnd := rand.New(rand.NewSource(1))
+		words := genWords(rnd, 50000)
+		corpora = []corpus{
+			{name: "dictionary", labels: words},
+			{name: "urls", labels: genURLs(rnd, words, 20000)},
+			{name: "github", labels: githubAPI},
+		}
+	})
+	return corpora
+}
+
+// genWords generates distinct words made of common English syllables.
+func genWords(rnd *rand.Rand, n int) []string {
+	syllables := strings.Fields(`a al an ar as at be ble ca ce co con de di
+		en er es ex fa fi for ge in is it la le li lo ly ma me mi mo na ne ni
+		no o per pre pro ra re ri ro sa se si so ta te ter ti tion to tu un ve vi`)
+	seen := make(map[string]bool, n)
+	words := make([]string, 0, n)
+	for len(words) < n {
+		var bd strings.Builder
+		for i := rnd.Intn(4); i >= 0; i-- {
+			bd.WriteString(syllables[rnd.Intn(len(syllables))])
+		}
+		if w := bd.String(); !seen[w] {
+			seen[w] = true
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// genURLs generates distinct URLs whose paths are made of words.
+func genURLs(rnd *rand.Rand, words []string, n int) []string {
+	hosts := make([]string, 200)
+	for i := range hosts {
+		hosts[i] = "https://" + words[rnd.Intn(len(words))] + []string{".com", ".org", ".net", ".io"}[rnd.Intn(4)]
+	}
+	seen := make(map[string]bool, n)
+	urls := make([]string, 0, n)
+	for len(urls) < n {
+		var bd strings.Builder
+		bd.WriteString(hosts[rnd.Intn(len(hosts))])
+		for i := rnd.Intn(4); i >= 0; i-- {
+			bd.WriteByte('/')
+			bd.WriteString(words[rnd.Intn(len(words))])
+		}
+		if u := bd.String(); !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// genIPs generates random IPv4 prefixes and addresses to be looked up.
+func genIPs(rnd *rand.Rand, n int) (prefixes [][]byte, bits []int, addrs [][]byte) {
+	for i := 0; i < n; i++ {
+		p := make([]byte, 4)
+		binary.BigEndian.PutUint32(p, rnd.Uint32())
+		prefixes = append(prefixes, p)
+		bits = append(bits, 8+rnd.Intn(25))
+		a := make([]byte, 4)
+		binary.BigEndian.PutUint32(a, rnd.Uint32())
+		addrs = append(addrs, a)
+	}
+	return prefixes, bits, addrs
+}
+
+func newBenchTree(labels []string) *Tree {
+	tr := New(0)
+	tr.SetBoundaries('@', '/')
+	for i, label := range labels {
+		tr.Add(label, i)
+	}
+	return tr
+}
+
+func newBenchMap(labels []string) map[string]interface{} {
+	m := make(map[string]interface{})
+	for i, label := range labels {
+		m[label] = i
+	}
+	return m
+}
+
 func BenchmarkTree(b *testing.B) {
+	b.Run("Get", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchTree.Get("rubicundus")
+		}
+	})
+	b.Run("Add", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchTree.Add("rubicundus", 7)
+		}
+	})
+}
+
+func BenchmarkAdd(b *testing.B) {
+	for _, c := range benchCorpora() {
+		labels := c.labels
+		for _, bc := range []struct {
+			name  string
+			flags int
+		}{{"radix", 0}, {"pool", Tpool}} {
+			flags := bc.flags
+			b.Run(c.name+"/"+bc.name, func(b *testing.B) {
+				b.ReportAllocs()
+				tr := New(flags)
+				for i := 0; i < b.N; i++ {
+					if i%len(labels) == 0 {
+						b.StopTimer()
+						tr = New(flags)
+						b.StartTimer()
+					}
+					tr.Add(labels[i%len(labels)], i)
+				}
+			})
+		}
+		b.Run(c.name+"/map", func(b *testing.B) {
+			b.ReportAllocs()
+			m := make(map[string]interface{})
+			for i := 0; i < b.N; i++ {
+				if i%len(labels) == 0 {
+					b.StopTimer()
+					m = make(map[string]interface{})
+					b.StartTimer()
+				}
+				m[labels[i%len(labels)]] = i
+			}
+		})
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	for _, c := range benchCorpora() {
+		labels := append([]string(nil), c.labels...)
+		sort.Strings(labels)
+		values := make([]interface{}, len(labels))
+		for i := range values {
+			values[i] = i
+		}
+		b.Run(c.name+"/sorted", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				FromSortedSlice(0, labels, values)
+			}
+		})
+		b.Run(c.name+"/add", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tr := New(0)
+				for j, label := range labels {
+					tr.Add(label, values[j])
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	for _, c := range benchCorpora() {
+		labels := c.labels
+		tr := newBenchTree(labels)
+		b.Run(c.name+"/radix", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tr.Get(labels[i%len(labels)])
+			}
+		})
+		data, err := tr.MarshalFlat(func(v interface{}) ([]byte, error) {
+			return []byte(strconv.Itoa(v.(int))), nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		ft, _ := NewFlat(data)
+		b.Run(c.name+"/flat", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ft.Get(labels[i%len(labels)])
+			}
+		})
+		s := tr.Freeze()
+		b.Run(c.name+"/succinct", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				s.Get(labels[i%len(labels)])
+			}
+		})
+		m := newBenchMap(labels)
+		b.Run(c.name+"/map", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = m[labels[i%len(labels)]]
+			}
+		})
+	}
+}
+
+func BenchmarkGetDynamic(b *testing.B) {
+	tr := newBenchTree(githubAPI)
+	paths := make([]string, len(githubAPI))
+	for i, route := range githubAPI {
+		segs := strings.Split(route, "/")
+		for j, seg := range segs {
+			if strings.HasPrefix(seg, "@") {
+				segs[j] = strconv.Itoa(i * j)
+			}
+		}
+		paths[i] = strings.Join(segs, "/")
+		if n, _ := tr.Get(paths[i]); n == nil || n.Value != i {
+			b.Fatalf("want %q to match %q", paths[i], route)
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Get(paths[i%len(paths)])
+	}
+}
+
+func BenchmarkDel(b *testing.B) {
+	for _, c := range benchCorpora() {
+		labels := c.labels
+		b.Run(c.name+"/radix", func(b *testing.B) {
+			b.ReportAllocs()
+			var tr *Tree
+			for i := 0; i < b.N; i++ {
+				if i%len(labels) == 0 {
+					b.StopTimer()
+					tr = newBenchTree(labels)
+					b.StartTimer()
+				}
+				tr.Del(labels[i%len(labels)])
+			}
+		})
+		b.Run(c.name+"/map", func(b *testing.B) {
+			b.ReportAllocs()
+			var m map[string]interface{}
+			for i := 0; i < b.N; i++ {
+				if i%len(labels) == 0 {
+					b.StopTimer()
+					m = newBenchMap(labels)
+					b.StartTimer()
+				}
+				delete(m, labels[i%len(labels)])
+			}
+		})
+	}
+}
+
+func BenchmarkSort(b *testing.B) {
+	for _, c := range benchCorpora() {
+		tr := newBenchTree(c.labels)
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tr.Sort(SortingTechnique(i % 3))
+			}
+		})
+	}
+}
+
+func BenchmarkString(b *testing.B) {
+	for _, c := range benchCorpora() {
+		tr := newBenchTree(c.labels)
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = tr.String()
+			}
+		})
+	}
+}
+
+// prefixKey is a map key of an IPv4 prefix, whose length
+// is needed to tell prefixes that have the same bits apart.
+type prefixKey struct {
+	v uint32
+	n int
+}
+
+func BenchmarkBinary(b *testing.B) {
+	prefixes, bits, addrs := genIPs(rand.New(rand.NewSource(1)), 20000)
+	tr := New(Tbinary)
+	m := make(map[prefixKey]interface{})
+	for i, p := range prefixes {
+		tr.AddBits(p, bits[i], i)
+		m[prefixKey{binary.BigEndian.Uint32(p) >> uint(32-bits[i]), bits[i]}] = i
+	}
+	b.Run("AddBits", func(b *testing.B) {
+		b.ReportAllocs()
+		tr := New(Tbinary)
+		for i := 0; i < b.N; i++ {
+			j := i % len(prefixes)
+			tr.AddBits(prefixes[j], bits[j], i)
+		}
+	})
+	b.Run("GetBits", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			j := i % len(prefixes)
+			tr.GetBits(prefixes[j], bits[j])
+		}
+	})
+	b.Run("LongestPrefixBits/radix", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tr.LongestPrefixBits(addrs[i%len(addrs)], 32)
+		}
+	})
+	// Maps need to be looked up once for each prefix length.
+	b.Run("LongestPrefixBits/map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			a := binary.BigEndian.Uint32(addrs[i%len(addrs)])
+			for n := uint(32); n >= 8; n-- {
+				if _, ok := m[prefixKey{a >> (32 - n), int(n)}]; ok {
+					break
+				}
+			}
+		}
+	})
+}
+
+// BenchmarkFanout looks up the last edge of nodes with increasing
+// numbers of edges, which would be the worst case for scanning them.
+func BenchmarkFanout(b *testing.B) {
+	for _, size := range []int{4, 16, 48, 255} {
+		tr := New(0)
+		var labels []string
+		for i := 1; i <= size; i++ {
+			label := string([]byte{byte(i), 'x'})
+			tr.Add(label, i)
+			tr.Add(label+"y", i)
+			labels = append(labels, label+"y")
+		}
+		last := labels[size-1]
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tr.Get(last)
+			}
+		})
+	}
 }
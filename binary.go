@@ -0,0 +1,305 @@
+package radix
+
+import "time"
+
+// AddBits adds a new node to the tree using the first nbits bits of key as its label.
+// Labels are read starting from the most significant bit of key's first byte.
+//
+// Note that this only works with binary trees.
+func (tr *Tree) AddBits(key []byte, nbits int, v interface{}) {
+	if !tr.binary || v == nil || nbits < 0 || nbits > len(key)*8 {
+		return
+	}
+	if tr.safe {
+		defer tr.mu.Unlock()
+		tr.mu.Lock()
+	}
+	label := maskBits(key, nbits)
+	tr.set(label, nbits, label, v, 0)
+}
+
+// DelBits deletes the node whose label is the first nbits bits of key.
+//
+// Note that this only works with binary trees.
+func (tr *Tree) DelBits(key []byte, nbits int) {
+	if !tr.binary || nbits < 0 || nbits > len(key)*8 {
+		return
+	}
+	if tr.safe {
+		defer tr.mu.Unlock()
+		tr.mu.Lock()
+	}
+	tr.unset(maskBits(key, nbits), nbits)
+}
+
+// GetBits retrieves the node whose label is the first nbits bits of key.
+//
+// Note that this only works with binary trees.
+func (tr *Tree) GetBits(key []byte, nbits int) *Node {
+	if !tr.binary || nbits < 0 || nbits > len(key)*8 {
+		return nil
+	}
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	n := tr.root.getBinary(b2s(key), nbits)
+	if n != nil && tr.expiring && n.expired(time.Now().UnixNano()) {
+		return nil
+	}
+	if n != nil && tr.bound != nil {
+		tr.used(n, b2s(key), true)
+	}
+	return n
+}
+
+// HasPrefixBits returns whether the tree holds any label
+// that starts with the first nbits bits of key.
+//
+// Note that this only works with binary trees.
+func (tr *Tree) HasPrefixBits(key []byte, nbits int) bool {
+	if !tr.binary || nbits < 0 || nbits > len(key)*8 {
+		return false
+	}
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	n := tr.root.seekBinary(b2s(key), nbits)
+	return n != nil && n.holdsAny(tr.now())
+}
+
+// LongestPrefixBits retrieves the node with the longest label
+// that is a prefix of the first nbits bits of key.
+//
+// Note that this only works with binary trees.
+func (tr *Tree) LongestPrefixBits(key []byte, nbits int) *Node {
+	if !tr.binary || nbits < 0 || nbits > len(key)*8 {
+		return nil
+	}
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	return tr.root.longestPrefixBinary(b2s(key), nbits, tr.now())
+}
+
+// WalkBits walks the tree calling fn for every node whose label
+// starts with the first nbits bits of key. If fn returns false, walking stops.
+//
+// Note that this only works with binary trees.
+func (tr *Tree) WalkBits(key []byte, nbits int, fn WalkFunc) {
+	if !tr.binary || nbits < 0 || nbits > len(key)*8 {
+		return
+	}
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	if tr.expiring {
+		fn = live(fn)
+	}
+	n := tr.root.seekBinary(b2s(key), nbits)
+	if n == nil || n.Value != nil && !fn(n.key, n) {
+		return
+	}
+	n.walk(fn)
+}
+
+func (tr *Tree) addBinary(label string, nbits int, key string, v interface{}, exp int64) (old interface{}) {
+	nn, bits, old := tr.root.addBinary(tr.pool, label, nbits, key, v, exp)
+	tr.length += nn
+	tr.bits += bits
+	return old
+}
+
+func (tr *Tree) delBinary(label string, nbits int) (key string, old interface{}) {
+	key, old, del, bits := tr.root.delBinary(label, nbits)
+	tr.length -= del
+	tr.bits -= bits
+	return key, old
+}
+
+// addBinary adds a value, which expires at exp, to the first nbits bits of label. It returns
+// how many nodes were created, how many bits were added to edges
+// and the node's previous value.
+//
+// Edges of binary trees hold the whole label that leads to their nodes,
+// but only bits between their parents' depths and their nodes' depths are relevant.
+// This way, only nodes that branch or hold values are created.
+func (n *Node) addBinary(p *pool, label string, nbits int, key string, v interface{}, exp int64) (nn, bits int, old interface{}) {
+	var buf [32]*Node
+	path := buf[:0]
+	for n.depth < nbits {
+		path = append(path, n)
+		bbit := bitAt(label, n.depth)
+		e := n.edges[bbit]
+		if e == nil {
+			n.edges[bbit] = p.newEdge(label, Node{
+				Value:   v,
+				key:     key,
+				expires: exp,
+				depth:   nbits,
+				edges:   make([]*edge, 2),
+				count:   1,
+			})
+			addCount(path, 1)
+			return nn + 1, nbits - n.depth, nil
+		}
+		end := e.n.depth
+		if nbits < end {
+			end = nbits
+		}
+		i := commonBits(e.label, label, n.depth+1, end)
+		if i == e.n.depth {
+			n = e.n
+			continue
+		}
+		// Split the edge where the labels diverge or where the new label ends.
+		//
+		// Example:
+		// 	(root) -> ("0110", v1)
+		// 	then add "0101"
+		// 	(root) -> ("01", nil) -> ("0101", v2)
+		// 	                      +> ("0110", v1)
+		n.edges[bbit] = p.newEdge(label, Node{
+			depth: i,
+			edges: make([]*edge, 2),
+			count: e.n.count + 1,
+		})
+		c := n.edges[bbit].n
+		c.edges[bitAt(e.label, i)] = e
+		addCount(path, 1)
+		if i == nbits {
+			c.Value = v
+			c.key = key
+			c.expires = exp
+			return nn + 1, bits, nil
+		}
+		c.edges[bitAt(label, i)] = p.newEdge(label, Node{
+			Value:   v,
+			key:     key,
+			expires: exp,
+			depth:   nbits,
+			edges:   make([]*edge, 2),
+			count:   1,
+		})
+		return nn + 2, nbits - i, nil
+	}
+	old = n.Value
+	if old == nil {
+		addCount(append(path, n), 1)
+	}
+	n.Value = v
+	n.key = key
+	n.expires = exp
+	return nn, bits, old
+}
+
+// delBinary deletes the value of the node at the first nbits bits of label.
+// Nodes that end up neither branching nor holding values are removed.
+// It returns the node's key and value, how many nodes were removed
+// and how many bits were removed from edges.
+func (n *Node) delBinary(label string, nbits int) (key string, old interface{}, del, bits int) {
+	var (
+		gparent, parent *Node
+		buf             [32]*Node
+	)
+	path := append(buf[:0], n)
+	for n.depth < nbits {
+		e := n.edges[bitAt(label, n.depth)]
+		if e == nil || !e.matchBinary(label, nbits, n.depth) {
+			return "", nil, 0, 0
+		}
+		gparent, parent, n = parent, n, e.n
+		path = append(path, n)
+	}
+	if n.Value == nil {
+		return "", nil, 0, 0
+	}
+	addCount(path, -1)
+	key, old = n.key, n.Value
+	n.Value = nil
+	n.key = ""
+	n.expires = 0
+	if parent == nil { // root
+		return key, old, 0, 0
+	}
+	switch e := n.edges; {
+	case e[0] != nil && e[1] != nil:
+		return key, old, 0, 0
+	case e[0] != nil:
+		parent.edges[bitAt(label, parent.depth)] = e[0]
+		return key, old, 1, 0
+	case e[1] != nil:
+		parent.edges[bitAt(label, parent.depth)] = e[1]
+		return key, old, 1, 0
+	}
+	parent.edges[bitAt(label, parent.depth)] = nil
+	del, bits = 1, n.depth-parent.depth
+	if gparent == nil || parent.Value != nil {
+		return key, old, del, bits
+	}
+	// Merge the parent with its remaining edge.
+	for _, e := range parent.edges {
+		if e != nil {
+			gparent.edges[bitAt(label, gparent.depth)] = e
+		}
+	}
+	return key, old, del + 1, bits
+}
+
+func (n *Node) getBinary(label string, nbits int) *Node {
+	for n.depth < nbits {
+		e := n.edges[bitAt(label, n.depth)]
+		if e == nil || !e.matchBinary(label, nbits, n.depth) {
+			return nil
+		}
+		n = e.n
+	}
+	return n
+}
+
+func (n *Node) longestPrefixBinary(label string, nbits int, now int64) *Node {
+	var match *Node
+	if n.holds(now) {
+		match = n
+	}
+	for n.depth < nbits {
+		e := n.edges[bitAt(label, n.depth)]
+		if e == nil || !e.matchBinary(label, nbits, n.depth) {
+			break
+		}
+		n = e.n
+		if n.holds(now) {
+			match = n
+		}
+	}
+	return match
+}
+
+// seekBinary returns the topmost node whose label
+// starts with the first nbits bits of label.
+func (n *Node) seekBinary(label string, nbits int) *Node {
+	for n.depth < nbits {
+		e := n.edges[bitAt(label, n.depth)]
+		if e == nil {
+			return nil
+		}
+		end := e.n.depth
+		if nbits < end {
+			end = nbits
+		}
+		if commonBits(e.label, label, n.depth, end) != end {
+			return nil
+		}
+		n = e.n
+	}
+	return n
+}
+
+// matchBinary returns whether the edge's bits after depth
+// are a prefix of the first nbits bits of label.
+func (e *edge) matchBinary(label string, nbits, depth int) bool {
+	return e.n.depth <= nbits && commonBits(e.label, label, depth, e.n.depth) == e.n.depth
+}
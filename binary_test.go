@@ -0,0 +1,90 @@
+package radix_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestBits(t *testing.T) {
+	type bits struct {
+		key   []byte
+		nbits int
+	}
+	keys := []bits{
+		{key: nil, nbits: 0},
+		{key: []byte{0xA0}, nbits: 3},       // 101
+		{key: []byte{0xAF}, nbits: 4},       // 1010, trailing bits are ignored
+		{key: []byte{0xC0}, nbits: 2},       // 11
+		{key: []byte{0xA5, 0x80}, nbits: 9}, // 101001011
+		{key: []byte{0x00}, nbits: 1},       // 0
+	}
+	tr := New(Tdebug | Tbinary)
+	for i, k := range keys {
+		tr.AddBits(k.key, k.nbits, i)
+	}
+	t.Log(tr.String())
+
+	for i, k := range keys {
+		n := tr.GetBits(k.key, k.nbits)
+		if n == nil {
+			t.Fatalf("want %x/%d to be found", k.key, k.nbits)
+		}
+		if want, got := i, n.Value; want != got {
+			t.Errorf("want %v, got %v", want, got)
+		}
+		if want, got := k.nbits, n.Depth(); want != got {
+			t.Errorf("want %d, got %d", want, got)
+		}
+	}
+	if n := tr.GetBits([]byte{0xA0}, 2); n != nil && n.Value != nil {
+		t.Errorf("want 10 not to hold a value")
+	}
+
+	prefixCases := []struct {
+		prefix bits
+		values []interface{}
+	}{
+		{prefix: bits{key: []byte{0x80}, nbits: 1}, values: []interface{}{1, 2, 4, 3}},
+		{prefix: bits{key: []byte{0xA0}, nbits: 4}, values: []interface{}{2, 4}},
+		{prefix: bits{key: []byte{0xA4}, nbits: 6}, values: []interface{}{4}},
+		{prefix: bits{key: []byte{0xE0}, nbits: 3}},
+		{prefix: bits{key: nil, nbits: 0}, values: []interface{}{0, 5, 1, 2, 4, 3}},
+	}
+	for _, tc := range prefixCases {
+		var values []interface{}
+		tr.WalkBits(tc.prefix.key, tc.prefix.nbits, func(_ string, n *Node) bool {
+			values = append(values, n.Value)
+			return true
+		})
+		if want, got := tc.values, values; !reflect.DeepEqual(want, got) {
+			t.Errorf("want %v, got %v", want, got)
+		}
+		if want, got := len(tc.values) > 0, tr.HasPrefixBits(tc.prefix.key, tc.prefix.nbits); want != got {
+			t.Errorf("want %t, got %t", want, got)
+		}
+	}
+
+	if n := tr.LongestPrefixBits([]byte{0xA7}, 8); n == nil || n.Value != 2 {
+		t.Errorf("want 10100111 to match 1010")
+	}
+	if n := tr.LongestPrefixBits([]byte{0x40}, 8); n == nil || n.Value != 5 {
+		t.Errorf("want 01000000 to match 0")
+	}
+
+	for i, k := range keys {
+		tr.DelBits(k.key, k.nbits)
+		if n := tr.GetBits(k.key, k.nbits); n != nil && n.Value != nil {
+			t.Errorf("want %x/%d to be deleted", k.key, k.nbits)
+		}
+		for j, k := range keys[i+1:] {
+			if n := tr.GetBits(k.key, k.nbits); n == nil || n.Value != i+j+1 {
+				t.Errorf("want %x/%d to be kept", k.key, k.nbits)
+			}
+		}
+	}
+	if want, got := 1, tr.Len(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
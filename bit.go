@@ -1,8 +1,33 @@
 package radix
 
-func bit(i uint8, c byte) uint8 {
-	if 1<<(i-1)&c > 0 {
-		return 1
+// bitAt returns the i-th most significant bit of s.
+func bitAt(s string, i int) uint8 {
+	return s[i/8] >> (7 - uint(i%8)) & 1
+}
+
+// maskBits returns the first nbits bits of b, zeroing the remaining
+// bits of the last byte.
+func maskBits(b []byte, nbits int) string {
+	m := make([]byte, (nbits+7)/8)
+	copy(m, b)
+	if r := nbits % 8; r > 0 {
+		m[len(m)-1] &= 0xFF << uint(8-r)
+	}
+	return string(m)
+}
+
+// commonBits returns the position of the first bit in [i, j)
+// that differs between a and b, or j if all of them are equal.
+func commonBits(a, b string, i, j int) int {
+	for i < j {
+		if i%8 == 0 && i+8 <= j && a[i/8] == b[i/8] { // compare whole bytes when possible
+			i += 8
+			continue
+		}
+		if bitAt(a, i) != bitAt(b, i) {
+			return i
+		}
+		i++
 	}
-	return 0
+	return i
 }
@@ -0,0 +1,106 @@
+package radix
+
+import "math/bits"
+
+// wordsPerRank is the number of words between precomputed ranks of bit vectors.
+const wordsPerRank = 8
+
+// bitVector is a sequence of bits that supports rank and select queries.
+type bitVector struct {
+	words []uint64
+	ranks []uint32 // number of ones before every wordsPerRank words
+	n     int
+}
+
+func (bv *bitVector) push(b bool) {
+	if bv.n%64 == 0 {
+		bv.words = append(bv.words, 0)
+	}
+	if b {
+		bv.words[bv.n/64] |= 1 << uint(bv.n%64)
+	}
+	bv.n++
+}
+
+// index precomputes ranks. It must be called after every bit is pushed.
+func (bv *bitVector) index() {
+	bv.ranks = make([]uint32, len(bv.words)/wordsPerRank+1)
+	var r uint32
+	for i, w := range bv.words {
+		if i%wordsPerRank == 0 {
+			bv.ranks[i/wordsPerRank] = r
+		}
+		r += uint32(bits.OnesCount64(w))
+	}
+	if len(bv.words)%wordsPerRank == 0 {
+		bv.ranks[len(bv.ranks)-1] = r
+	}
+}
+
+func (bv *bitVector) get(i int) bool {
+	return bv.words[i/64]&(1<<uint(i%64)) != 0
+}
+
+// rank1 returns the number of ones before position i.
+func (bv *bitVector) rank1(i int) int {
+	w := i / 64
+	r := int(bv.ranks[w/wordsPerRank])
+	for j := w / wordsPerRank * wordsPerRank; j < w; j++ {
+		r += bits.OnesCount64(bv.words[j])
+	}
+	if i%64 > 0 {
+		r += bits.OnesCount64(bv.words[w] & (1<<uint(i%64) - 1))
+	}
+	return r
+}
+
+// rank0 returns the number of zeros before position i.
+func (bv *bitVector) rank0(i int) int {
+	return i - bv.rank1(i)
+}
+
+// select1 returns the position of the k-th one, starting from 1.
+func (bv *bitVector) select1(k int) int {
+	return bv.selectBit(k, false)
+}
+
+// select0 returns the position of the k-th zero, starting from 1.
+func (bv *bitVector) select0(k int) int {
+	return bv.selectBit(k, true)
+}
+
+func (bv *bitVector) selectBit(k int, zero bool) int {
+	count := func(b int) int { // bits before block b
+		r := int(bv.ranks[b])
+		if zero {
+			return b*wordsPerRank*64 - r
+		}
+		return r
+	}
+	// Find the last block with less than k bits before it.
+	lo, hi := 0, len(bv.ranks)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if count(mid) < k {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	k -= count(lo)
+	for w := lo * wordsPerRank; w < len(bv.words); w++ {
+		word := bv.words[w]
+		if zero {
+			word = ^word
+		}
+		if c := bits.OnesCount64(word); c < k {
+			k -= c
+			continue
+		}
+		for ; k > 1; k-- {
+			word &= word - 1 // clear the lowest bit
+		}
+		return w*64 + bits.TrailingZeros64(word)
+	}
+	return bv.n
+}
@@ -0,0 +1,252 @@
+package radix
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// Policy is how bounded trees choose which labels to evict.
+type Policy int
+
+const (
+	// LRU evicts the labels that were least recently added or retrieved with Get or GetBits.
+	LRU Policy = iota
+	// LFU evicts the labels that were least frequently added, according to the priorities
+	// of their nodes, which count how many times labels were added through them.
+	// Labels are chosen by following the edges with the lowest priorities,
+	// so labels under popular prefixes are evicted last.
+	//
+	// Note that this doesn't work with binary trees, which use LRU instead.
+	LFU
+)
+
+// EvictFunc is the function called for each label evicted from a bounded tree.
+// It's called while the tree is locked, so it must not call methods of the tree.
+type EvictFunc func(key string, v interface{})
+
+// bound holds the capacity of a tree and, for LRU,
+// the order in which its labels were used.
+type bound struct {
+	count  int
+	size   int
+	policy Policy
+	evict  EvictFunc
+	mu     sync.Mutex // Get changes the order while the tree is only read-locked
+	order  *list.List // of targets, most recently used first
+	elems  map[target]*list.Element
+}
+
+// SetCapacity bounds the tree to hold at most count values and size bytes,
+// as returned by Size, where zero means unbounded. When adding or merging labels
+// would exceed them, labels are evicted according to policy, and fn,
+// if not nil, is called for each evicted label. Evicted labels are also
+// reported to watchers and observers as deleted. A label is added
+// even if it alone exceeds the tree's size.
+//
+// Values that expired count toward the capacity until they're deleted.
+// Passing zero for both count and size makes the tree unbounded again.
+func (tr *Tree) SetCapacity(count, size int, policy Policy, fn EvictFunc) {
+	if tr.safe {
+		defer tr.mu.Unlock()
+		tr.mu.Lock()
+	}
+	if count <= 0 && size <= 0 {
+		tr.bound = nil
+		return
+	}
+	if tr.binary {
+		policy = LRU
+	}
+	b := &bound{count: count, size: size, policy: policy, evict: fn}
+	if policy == LRU {
+		b.order = list.New()
+		b.elems = make(map[target]*list.Element)
+		var ts []target
+		if tr.root.Value != nil { // only binary trees have roots with values
+			ts = append(ts, target{})
+		}
+		for _, t := range tr.collect(tr.root, nil, "", func(*Node) bool { return true }, ts) {
+			b.elems[t] = b.order.PushBack(t)
+		}
+	}
+	tr.bound = b
+	tr.shrink()
+}
+
+// shrink evicts labels until the tree is within its capacity.
+func (tr *Tree) shrink() {
+	for tr.root.count > 0 && !tr.fits(0, 0) {
+		tr.evictOne()
+	}
+}
+
+// makeRoom evicts labels until the first nbits bits of label,
+// which is already transformed, can be added without exceeding the tree's capacity.
+func (tr *Tree) makeRoom(label string, nbits int) {
+	if tr.find(label, nbits) != nil { // only replaced
+		return
+	}
+	for tr.root.count > 0 {
+		// Evicting labels may remove bytes the label shares with others.
+		var size int
+		if tr.bound.size > 0 {
+			size = tr.growth(label, nbits)
+		}
+		if tr.fits(1, size) {
+			return
+		}
+		tr.evictOne()
+	}
+}
+
+// fits returns whether count values and size bytes can be added
+// without exceeding the tree's capacity.
+func (tr *Tree) fits(count, size int) bool {
+	b := tr.bound
+	return (b.count <= 0 || tr.root.count+count <= b.count) &&
+		(b.size <= 0 || tr.Size()+size <= b.size)
+}
+
+// growth returns by how many bytes adding the first nbits bits of label,
+// which is already transformed, grows the tree's size, which only
+// counts the bytes that aren't shared with labels the tree holds.
+func (tr *Tree) growth(label string, nbits int) int {
+	if tr.binary {
+		n := tr.root
+		for n.depth < nbits {
+			e := n.edges[bitAt(label, n.depth)]
+			if e == nil {
+				break
+			}
+			end := e.n.depth
+			if nbits < end {
+				end = nbits
+			}
+			if i := commonBits(e.label, label, n.depth+1, end); i < e.n.depth {
+				return (tr.bits+nbits-i+7)/8 - tr.Size()
+			}
+			n = e.n
+		}
+		return (tr.bits+nbits-n.depth+7)/8 - tr.Size()
+	}
+	n := tr.root
+	for label != "" {
+		var next *edge
+		for _, e := range n.lookup(label[0]) {
+			i := 0
+			for i < len(e.label) && i < len(label) && e.label[i] == label[i] {
+				i++
+			}
+			if i = tr.cut(e.label, label, i); i == len(e.label) {
+				next = e
+				break
+			}
+			if i > 0 { // the edge is split
+				return len(label) - i
+			}
+		}
+		if next == nil {
+			break
+		}
+		n = next.n
+		label = label[len(next.label):]
+	}
+	return len(label)
+}
+
+// evictOne evicts a label of the tree, which must hold any.
+// Labels that LRU doesn't track, if any, are evicted like LFU does.
+func (tr *Tree) evictOne() {
+	var t target
+	if e := tr.bound.order; tr.bound.policy == LRU && e.Len() > 0 {
+		t = e.Back().Value.(target)
+	} else {
+		t = tr.leastFrequent()
+	}
+	key, old := tr.unset(t.label, t.nbits)
+	if old != nil && tr.bound.evict != nil {
+		tr.bound.evict(key, old)
+	}
+}
+
+// leastFrequent returns the target of the leaf reached by following
+// the edges with the lowest priorities.
+func (tr *Tree) leastFrequent() target {
+	var (
+		label []byte
+		last  *edge
+	)
+	n := tr.root
+	for !n.IsLeaf() {
+		var min *edge
+		for _, e := range n.edges {
+			if e != nil && (min == nil || e.n.priority < min.n.priority) {
+				min = e
+			}
+		}
+		if !tr.binary {
+			label = append(label, min.label...)
+		}
+		n, last = min.n, min
+	}
+	if tr.binary {
+		if last == nil { // only the root holds a value
+			return target{}
+		}
+		return tr.target(last.label, n.depth)
+	}
+	return target{string(label), len(label) * 8}
+}
+
+// used marks n, which was retrieved by label, which is already transformed,
+// as the most recently used label. If label matched n statically,
+// it's how n's label is stored. Otherwise, it matched named labels or wildcards,
+// so n's key is transformed again instead.
+func (tr *Tree) used(n *Node, label string, static bool) {
+	if n.Value == nil || tr.bound.policy != LRU {
+		return
+	}
+	if !static || tr.host && strings.IndexByte(n.key, '*') >= 0 {
+		label = tr.key(n.key)
+	}
+	var t target
+	if tr.binary {
+		t = tr.target(label, n.depth)
+	} else {
+		t = target{label, len(label) * 8}
+	}
+	b := tr.bound
+	b.mu.Lock()
+	// Labels are tracked when they're added, and label may reference
+	// the caller's bytes, so it's never added here.
+	if e, ok := b.elems[t]; ok {
+		b.order.MoveToFront(e)
+	}
+	b.mu.Unlock()
+}
+
+func (b *bound) touch(t target) {
+	if b.policy != LRU {
+		return
+	}
+	b.mu.Lock()
+	if e, ok := b.elems[t]; ok {
+		b.order.MoveToFront(e)
+	} else {
+		b.elems[t] = b.order.PushFront(t)
+	}
+	b.mu.Unlock()
+}
+
+func (b *bound) untrack(t target) {
+	if b.policy != LRU {
+		return
+	}
+	b.mu.Lock()
+	if e, ok := b.elems[t]; ok {
+		b.order.Remove(e)
+		delete(b.elems, t)
+	}
+	b.mu.Unlock()
+}
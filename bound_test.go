@@ -0,0 +1,195 @@
+package radix_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestSetCapacity(t *testing.T) {
+	testCases := []int{0, Tsafe, Tbinary, Tfold, Treverse, Tpool}
+	for _, flags := range testCases {
+		t.Run("", func(t *testing.T) {
+			var evicted []string
+			tr := New(flags)
+			tr.SetCapacity(2, 0, LRU, func(key string, v interface{}) {
+				evicted = append(evicted, key)
+			})
+			tr.Add("romane", 1)
+			tr.Add("romanus", 2)
+			tr.Get("romane")
+			tr.Add("romulus", 3)
+			tr.Add("romane", 4) // only replaced
+			if want, got := []string{"romanus"}, evicted; !reflect.DeepEqual(want, got) {
+				t.Errorf("want %q, got %q", want, got)
+			}
+			if want, got := 2, tr.Count(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			tr.Del("romane")
+			tr.Add("rubens", 5)
+			tr.Add("ruber", 6)
+			if want, got := []string{"romanus", "romulus"}, evicted; !reflect.DeepEqual(want, got) {
+				t.Errorf("want %q, got %q", want, got)
+			}
+
+			// Bounding a tree evicts its labels right away.
+			tr.SetCapacity(1, 0, LRU, nil)
+			if want, got := 1, tr.Count(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			tr.SetCapacity(0, 0, LRU, nil)
+			tr.Add("rubicon", 7)
+			if want, got := 2, tr.Count(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+		})
+	}
+
+	// Sizes are also bounded.
+	tr := New(0)
+	tr.SetCapacity(0, 12, LRU, nil)
+	for i, label := range []string{"romane", "romanus", "romulus", "rubens", "ruber"} {
+		tr.Add(label, i)
+		if tr.Size() > 12 {
+			t.Errorf("want size of at most %d, got %d", 12, tr.Size())
+		}
+	}
+	if n, _ := tr.Get("ruber"); n == nil {
+		t.Errorf("want %q to be kept", "ruber")
+	}
+
+	// Only bytes that aren't shared with other labels count.
+	for _, flags := range []int{0, Tbinary} {
+		var evicted []string
+		tr := New(flags)
+		tr.SetCapacity(0, 12, LRU, func(key string, v interface{}) {
+			evicted = append(evicted, key)
+		})
+		for i, label := range []string{"romane", "romanus", "romanes", "romanum"} {
+			tr.Add(label, i)
+		}
+		if want, got := []string(nil), evicted; !reflect.DeepEqual(want, got) {
+			t.Errorf("want %q, got %q", want, got)
+		}
+		if want, got := 4, tr.Count(); want != got {
+			t.Errorf("want %d, got %d", want, got)
+		}
+		tr.Add("rubens", 4)
+		if len(evicted) == 0 || evicted[0] != "romane" {
+			t.Errorf("want %q to be evicted first, got %q", "romane", evicted)
+		}
+		if n, _ := tr.Get("rubens"); n == nil || tr.Size() > 12 {
+			t.Errorf("want %q to be added within size %d, got size %d", "rubens", 12, tr.Size())
+		}
+	}
+}
+
+func TestSetCapacityLFU(t *testing.T) {
+	var evicted []string
+	tr := New(0)
+	tr.SetCapacity(3, 0, LFU, func(key string, v interface{}) {
+		evicted = append(evicted, key)
+	})
+	for i := 0; i < 3; i++ {
+		tr.Add("romane", i)
+	}
+	tr.Add("romanus", 1)
+	tr.Add("rubens", 2)
+	tr.Add("rubens", 3)
+	tr.Add("ruber", 4)
+	tr.Add("romulus", 5)
+	if want, got := []string{"rubens", "ruber"}, evicted; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if want, got := 3, tr.Count(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
+
+func TestSetCapacitySetOperations(t *testing.T) {
+	testCases := []int{0, Tsafe, Tbinary, Tfold, Treverse, Tpool}
+	for _, flags := range testCases {
+		for _, otherFlags := range []int{flags, Thost} {
+			t.Run("", func(t *testing.T) {
+				var evicted []string
+				tr := New(flags)
+				tr.SetCapacity(2, 0, LRU, func(key string, v interface{}) {
+					evicted = append(evicted, key)
+				})
+				tr.Add("romane", 1)
+				other := New(otherFlags)
+				for i, label := range []string{"romanus", "romulus", "rubens", "ruber"} {
+					other.Add(label, i)
+				}
+				tr.Merge(other, nil)
+				if want, got := 2, tr.Count(); want != got {
+					t.Errorf("want %d, got %d", want, got)
+				}
+				tr.Add("rubicon", 5)
+				if want, got := 2, tr.Count(); want != got {
+					t.Errorf("want %d, got %d", want, got)
+				}
+
+				// Pruned labels are no longer evicted.
+				other = New(otherFlags)
+				other.Add("rubicon", 0)
+				tr.Intersect(other, nil)
+				evicted = nil
+				tr.Add("rubicundus", 6)
+				tr.Add("romane", 7)
+				if want, got := []string{"rubicon"}, evicted; !reflect.DeepEqual(want, got) {
+					t.Errorf("want %q, got %q", want, got)
+				}
+				tr.Difference(tr)
+				evicted = nil
+				tr.Add("romanus", 8)
+				tr.Add("romulus", 9)
+				tr.Add("rubens", 10)
+				if want, got := []string{"romanus"}, evicted; !reflect.DeepEqual(want, got) {
+					t.Errorf("want %q, got %q", want, got)
+				}
+			})
+		}
+	}
+
+	tr := New(0)
+	tr.SetCapacity(2, 0, LFU, nil)
+	other := New(0)
+	for i, label := range []string{"romane", "romanus", "romulus"} {
+		other.Add(label, i)
+	}
+	tr.Merge(other, nil)
+	if want, got := 2, tr.Count(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
+
+func TestSetCapacityRetrieved(t *testing.T) {
+	var evicted []string
+	evict := func(key string, v interface{}) {
+		evicted = append(evicted, key)
+	}
+
+	// Labels that match patterns refresh their patterns.
+	tr := New(0)
+	tr.SetBoundaries('@', '/')
+	tr.SetCapacity(2, 0, LRU, evict)
+	tr.Add("/users/@id", 1)
+	tr.Add("/about", 2)
+	tr.Get("/users/42")
+	tr.Add("/contact", 3)
+
+	// Bit strings are refreshed as they're stored, even in case-insensitive trees.
+	tr = New(Tbinary | Tfold)
+	tr.SetCapacity(2, 0, LRU, evict)
+	tr.AddBits([]byte("A"), 8, 1)
+	tr.AddBits([]byte("B"), 8, 2)
+	tr.GetBits([]byte("A"), 8)
+	tr.AddBits([]byte("C"), 8, 3)
+
+	if want, got := []string{"/about", "B"}, evicted; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
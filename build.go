@@ -0,0 +1,165 @@
+package radix
+
+import (
+	"errors"
+	"sort"
+)
+
+var (
+	// ErrUnsorted is returned when building a tree from labels
+	// that are not in strictly ascending order.
+	ErrUnsorted = errors.New("radix: labels are not sorted")
+
+	errLength = errors.New("radix: labels and values differ in length")
+)
+
+// Iterator returns the next label and value to be added to a tree.
+// When there are no more labels, it returns false.
+type Iterator func() (label string, v interface{}, ok bool)
+
+// BuildSorted builds a tree from labels returned by it, which must be
+// in strictly ascending order, or else ErrUnsorted is returned.
+// Empty labels and nil values are skipped, just like in (*Tree).Add.
+//
+// Prefix trees are built in a single pass, without walking from the root
+// for each label. Trees whose labels are stored transformed, that is,
+// binary, case-insensitive, reversed and hostname trees, fall back to (*Tree).Add.
+func BuildSorted(flags int, it Iterator) (*Tree, error) {
+	tr := New(flags)
+	if tr.binary || tr.fold || tr.reverse || tr.host {
+		var prev string
+		for {
+			label, v, ok := it()
+			if !ok {
+				return tr, nil
+			}
+			if label == "" || v == nil {
+				continue
+			}
+			if prev != "" && label <= prev {
+				return nil, ErrUnsorted
+			}
+			prev = label
+			tr.add(label, v, 0)
+		}
+	}
+	ld := loader{tr: tr, stack: []frame{{n: tr.root}}}
+	for {
+		label, v, ok := it()
+		if !ok {
+			break
+		}
+		if label == "" || v == nil {
+			continue
+		}
+		if ld.prev != "" && label <= ld.prev {
+			return nil, ErrUnsorted
+		}
+		ld.add(label, v)
+	}
+	tr.root.finish(0)
+	return tr, nil
+}
+
+// FromSortedSlice builds a tree from labels in strictly ascending order,
+// where values[i] is the value of labels[i]. See BuildSorted for details.
+func FromSortedSlice(flags int, labels []string, values []interface{}) (*Tree, error) {
+	if len(labels) != len(values) {
+		return nil, errLength
+	}
+	i := 0
+	return BuildSorted(flags, func() (string, interface{}, bool) {
+		if i == len(labels) {
+			return "", nil, false
+		}
+		i++
+		return labels[i-1], values[i-1], true
+	})
+}
+
+// FromMap builds a tree from the labels and values of m.
+func FromMap(flags int, m map[string]interface{}) *Tree {
+	labels := make([]string, 0, len(m))
+	for label := range m {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	values := make([]interface{}, len(labels))
+	for i, label := range labels {
+		values[i] = m[label]
+	}
+	tr, _ := FromSortedSlice(flags, labels, values) // labels are unique and sorted
+	return tr
+}
+
+// frame is a node in the path to the last label added by a loader.
+type frame struct {
+	n   *Node
+	off int // length of the label that leads to the node
+}
+
+// loader adds sorted labels to a tree. Since labels are sorted,
+// only edges in the path to the last added label can still be split.
+type loader struct {
+	tr    *Tree
+	prev  string
+	stack []frame
+}
+
+func (ld *loader) add(label string, v interface{}) {
+	tr := ld.tr
+	i := 0
+	for i < len(ld.prev) && i < len(label) && ld.prev[i] == label[i] {
+		i++
+	}
+	i = tr.cut(ld.prev, label, i)
+	ld.prev = label
+
+	// Nodes deeper than the common prefix won't get any new edges.
+	var last frame
+	for ld.stack[len(ld.stack)-1].off > i {
+		last = ld.stack[len(ld.stack)-1]
+		ld.stack = ld.stack[:len(ld.stack)-1]
+	}
+	top := ld.stack[len(ld.stack)-1]
+	if top.off < i {
+		// Split the edge that leads to the last popped node.
+		//
+		// Example:
+		// 	(root) -> ("tomato", v1)
+		// 	then add "tornado"
+		// 	(root) -> ("to", nil) -> ("mato", v1)
+		// 	                      +> ("rnado", v2)
+		e := top.n.edges[len(top.n.edges)-1]
+		c := tr.pool.newEdge(e.label[:i-top.off], Node{
+			priority: last.n.priority,
+			count:    last.n.count,
+		})
+		e.label = e.label[i-top.off:]
+		c.n.edges = []*edge{e}
+		top.n.edges[len(top.n.edges)-1] = c
+		top = frame{n: c.n, off: i}
+		ld.stack = append(ld.stack, top)
+		tr.length++
+	}
+	e := tr.pool.newEdge(label[i:], Node{Value: v, key: label})
+	top.n.edges = append(top.n.edges, e)
+	ld.stack = append(ld.stack, frame{n: e.n, off: len(label)})
+	for _, f := range ld.stack[1:] {
+		f.n.priority++
+	}
+	for _, f := range ld.stack {
+		f.n.count++
+	}
+	tr.length++
+	tr.size += len(label) - i
+}
+
+// finish sets the depths and builds the indices of n and its children.
+func (n *Node) finish(depth int) {
+	n.depth = depth
+	n.reindex()
+	for _, e := range n.edges {
+		e.n.finish(depth + 1)
+	}
+}
@@ -0,0 +1,108 @@
+package radix_test
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestBuildSorted(t *testing.T) {
+	words := []string{
+		"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus",
+		"test", "toaster", "toasting", "slow", "slowly", "tom", "tomato", "tornado",
+		"a", "ab", "abc", "b", "áb", "ác", "日本", "日本語", "日月",
+	}
+	for i := 0; i < 500; i++ {
+		words = append(words, strconv.Itoa(i*7919))
+	}
+	sort.Strings(words)
+	values := make([]interface{}, len(words))
+	for i := range values {
+		values[i] = i
+	}
+	testCases := []int{0, Trune, Tfold, Treverse, Thost, Tbinary, Tpool}
+	for _, flags := range testCases {
+		t.Run("", func(t *testing.T) {
+			want := New(flags | Tdebug)
+			for i, w := range words {
+				want.Add(w, i)
+			}
+			got, err := FromSortedSlice(flags|Tdebug, words, values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, got := want.Len(), got.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			if want, got := want.Size(), got.Size(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			if want, got := want.String(), got.String(); want != got {
+				t.Errorf("want %q, got %q", want, got)
+			}
+			depths := make(map[string]int)
+			want.Walk(func(key string, n *Node) bool {
+				depths[key] = n.Depth()
+				return true
+			})
+			got.Walk(func(key string, n *Node) bool {
+				if want, got := depths[key], n.Depth(); want != got {
+					t.Errorf("want %d, got %d", want, got)
+				}
+				return true
+			})
+			for i, w := range words {
+				if n, _ := got.Get(w); n == nil || n.Value != i {
+					t.Fatalf("want %q to be found", w)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildSortedErrors(t *testing.T) {
+	testCases := []struct {
+		labels []string
+		err    error
+	}{
+		{[]string{"a", "b", "c"}, nil},
+		{[]string{"a", "", "b"}, nil},
+		{[]string{"b", "a"}, ErrUnsorted},
+		{[]string{"a", "a"}, ErrUnsorted},
+		{[]string{"ab", "a"}, ErrUnsorted},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			values := make([]interface{}, len(tc.labels))
+			for i := range values {
+				values[i] = i
+			}
+			if _, err := FromSortedSlice(0, tc.labels, values); err != tc.err {
+				t.Errorf("want %v, got %v", tc.err, err)
+			}
+		})
+	}
+	if _, err := FromSortedSlice(0, []string{"a"}, nil); err == nil {
+		t.Error("want an error")
+	}
+}
+
+func TestFromMap(t *testing.T) {
+	m := map[string]interface{}{
+		"romane":  1,
+		"romanus": 2,
+		"romulus": 3,
+		"rubens":  4,
+	}
+	tr := FromMap(Tnocolor, m)
+	if want, got := 8, tr.Len(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	for label, v := range m {
+		if n, _ := tr.Get(label); n == nil || n.Value != v {
+			t.Errorf("want %q to be found", label)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 type builder struct {
 	*strings.Builder
-	colors [4]color.Color
-	debug  bool
+	colors  [4]color.Color
+	debug   bool
+	reverse bool
 }
@@ -0,0 +1,40 @@
+package radix
+
+import "unsafe"
+
+// AddBytes adds a new node to the tree. The label is copied.
+func (tr *Tree) AddBytes(label []byte, v interface{}) {
+	tr.Add(string(label), v)
+}
+
+// DelBytes deletes a node.
+func (tr *Tree) DelBytes(label []byte) {
+	tr.Del(b2s(label))
+}
+
+// GetBytes retrieves a node without converting label to a string.
+// Named parameters are copied, so label can be safely reused after it returns.
+func (tr *Tree) GetBytes(label []byte) (*Node, map[string]string) {
+	n, params := tr.Get(b2s(label))
+	for k, v := range params {
+		params[k] = string([]byte(v))
+	}
+	return n, params
+}
+
+// WalkBytes works like Walk, but passes keys as byte slices.
+// In order to avoid allocations, the same slice is reused between calls,
+// so it's only valid until fn returns.
+func (tr *Tree) WalkBytes(fn func(key []byte, n *Node) bool) {
+	var buf []byte
+	tr.Walk(func(key string, n *Node) bool {
+		buf = append(buf[:0], key...)
+		return fn(buf, n)
+	})
+}
+
+// b2s converts b to a string without copying it,
+// so the string must not be retained by the tree.
+func b2s(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
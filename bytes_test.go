@@ -0,0 +1,46 @@
+package radix_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestBytes(t *testing.T) {
+	tr := New(0)
+	tr.SetBoundaries('@', '/')
+	buf := []byte("/users/@id")
+	tr.AddBytes(buf, 1)
+	tr.AddBytes([]byte("/static"), 2)
+	copy(buf, "/xxxxx/") // the tree must hold a copy
+
+	buf = append(buf[:0], "/users/123"...)
+	n, p := tr.GetBytes(buf)
+	if n == nil {
+		t.Fatalf("want %q to be found", buf)
+	}
+	copy(buf, "/users/456") // parameters must not point to buf
+	if want, got := map[string]string{"id": "123"}, p; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	static := []byte("/static")
+	if allocs := testing.AllocsPerRun(100, func() { tr.GetBytes(static) }); allocs > 0 {
+		t.Errorf("want no allocations, got %v", allocs)
+	}
+
+	var keys []string
+	tr.WalkBytes(func(key []byte, _ *Node) bool {
+		keys = append(keys, string(key))
+		return true
+	})
+	if want, got := []string{"/users/@id", "/static"}, keys; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	tr.DelBytes(static)
+	if n, _ := tr.GetBytes(static); n != nil {
+		t.Errorf("want %q to be deleted", static)
+	}
+}
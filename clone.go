@@ -0,0 +1,36 @@
+package radix
+
+import (
+	"strings"
+	"sync"
+)
+
+// Clone returns a deep copy of the tree, which keeps the tree's flags, boundaries,
+// normalizer and the order of its edges, so it can be changed without affecting the tree.
+// Watchers and observers of the tree aren't notified of changes to the copy,
+// the tree's janitor doesn't delete expired values of the copy and the copy is unbounded.
+func (tr *Tree) Clone() *Tree {
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	return tr.clone()
+}
+
+func (tr *Tree) clone() *Tree {
+	c := *tr
+	c.watchers, c.observers, c.janitor, c.bound = nil, nil, nil, nil
+	if tr.safe {
+		c.mu = &sync.RWMutex{}
+	}
+	if tr.pool != nil {
+		c.pool = &pool{}
+	}
+	bd := *tr.bd
+	bd.Builder = &strings.Builder{}
+	c.bd = &bd
+	c.root = c.cloneEdge("", tr.root, -1).n
+	// Counters were increased while copying.
+	c.length, c.size, c.bits = tr.length, tr.size, tr.bits
+	return &c
+}
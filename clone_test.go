@@ -0,0 +1,63 @@
+package radix_test
+
+import (
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestClone(t *testing.T) {
+	testCases := []int{0, Tsafe | Tdebug, Tfold, Treverse, Tbinary, Tpool, Thost}
+	for _, flags := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(flags)
+			for i, label := range []string{"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus"} {
+				tr.Add(label, i)
+			}
+			tr.Sort(DescLabelSort)
+			s := tr.String()
+			c := tr.Clone()
+			if want, got := s, c.String(); want != got {
+				t.Errorf("want %q, got %q", want, got)
+			}
+			if want, got := tr.Len(), c.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			if want, got := tr.Size(), c.Size(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			if want, got := tr.Count(), c.Count(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+
+			// Changing the clone doesn't affect the tree.
+			n, _ := c.Get("romanus")
+			n.Value = -1
+			c.Add("romanes", 7)
+			c.Del("rubicon")
+			c.Sort(AscLabelSort)
+			if want, got := s, tr.String(); want != got {
+				t.Errorf("want %q, got %q", want, got)
+			}
+			if n, _ := tr.Get("romanus"); n == nil || n.Value != 1 {
+				t.Errorf("want %d, got %v", 1, n)
+			}
+			if n, _ := c.Get("romanes"); n == nil || n.Value != 7 {
+				t.Errorf("want %d, got %v", 7, n)
+			}
+			if n, _ := c.Get("rubicon"); n != nil {
+				t.Errorf("want %q to be deleted", "rubicon")
+			}
+		})
+	}
+
+	// Boundaries and normalizers are also cloned.
+	tr := New(0)
+	tr.SetBoundaries(':', '/')
+	tr.SetNormalizer(func(s string) string { return s + "/" })
+	tr.Add("/users/:id", 1)
+	n, p := tr.Clone().Get("/users/42")
+	if n == nil || p["id"] != "42" {
+		t.Errorf("want %q to be matched, got %v", "/users/42", p)
+	}
+}
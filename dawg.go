@@ -0,0 +1,253 @@
+package radix
+
+import (
+	"encoding/binary"
+	"sort"
+	"strings"
+)
+
+// DAWG is a read-only directed acyclic word graph, that is, a minimal automaton
+// that shares both prefixes and suffixes of labels, which makes it much smaller
+// than a tree for labels with common endings, such as inflected words or paths.
+//
+// Values are attached to labels by their positions among all labels.
+// Each state counts how many labels can be reached from it, so a label's
+// position is computed while reading it.
+type DAWG struct {
+	states []state
+	trans  []transition
+	values []interface{}
+	tr     *Tree // transforms labels just like the tree it was built from
+}
+
+type state struct {
+	trans int32 // position of the state's first transition
+	n     int32 // number of transitions
+	count int32 // number of labels reachable from the state
+	final bool
+}
+
+type transition struct {
+	c  byte
+	to int32
+}
+
+// Minimize builds a DAWG from the labels of the tree as they're stored.
+// Expired values are skipped.
+//
+// Binary trees can't be minimized, so nil is returned for them.
+func (tr *Tree) Minimize() *DAWG {
+	if tr.binary {
+		return nil
+	}
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	db := newDAWGBuilder()
+	now := tr.now()
+	var visit func(n *Node, label string)
+	visit = func(n *Node, label string) {
+		if n.holds(now) {
+			db.add(label, n.Value)
+		}
+		edges := make([]*edge, len(n.edges))
+		copy(edges, n.edges)
+		sort.Slice(edges, func(i, j int) bool { return edges[i].label < edges[j].label })
+		for _, e := range edges {
+			visit(e.n, label+e.label)
+		}
+	}
+	visit(tr.root, "")
+	d := db.build()
+	d.tr = &Tree{
+		fold:      tr.fold,
+		reverse:   tr.reverse,
+		host:      tr.host,
+		delim:     tr.delim,
+		normalize: tr.normalize,
+	}
+	return d
+}
+
+// BuildDAWG builds a DAWG from labels returned by it, which must be
+// in strictly ascending order, or else ErrUnsorted is returned.
+// Empty labels and nil values are skipped.
+func BuildDAWG(it Iterator) (*DAWG, error) {
+	db := newDAWGBuilder()
+	for {
+		label, v, ok := it()
+		if !ok {
+			break
+		}
+		if label == "" || v == nil {
+			continue
+		}
+		if db.prev != "" && label <= db.prev {
+			return nil, ErrUnsorted
+		}
+		db.add(label, v)
+	}
+	d := db.build()
+	d.tr = &Tree{}
+	return d, nil
+}
+
+// Get retrieves the value of label.
+func (d *DAWG) Get(label string) (interface{}, bool) {
+	if label == "" {
+		return nil, false
+	}
+	label = d.tr.key(label)
+	s, i := int32(0), 0
+	for j := 0; j < len(label); j++ {
+		st := &d.states[s]
+		if st.final {
+			i++
+		}
+		next := int32(-1)
+		for _, t := range d.trans[st.trans : st.trans+st.n] {
+			if t.c == label[j] {
+				next = t.to
+				break
+			}
+			i += int(d.states[t.to].count)
+		}
+		if next < 0 {
+			return nil, false
+		}
+		s = next
+	}
+	if !d.states[s].final {
+		return nil, false
+	}
+	return d.values[i], true
+}
+
+// Len returns the number of labels in the DAWG.
+func (d *DAWG) Len() int {
+	return len(d.values)
+}
+
+// Walk calls fn for every label and value in ascending order of how labels are stored.
+// Labels of case-insensitive trees are passed folded. If fn returns false, walking stops.
+func (d *DAWG) Walk(fn func(key string, v interface{}) bool) {
+	i := 0
+	d.walk(0, nil, &i, fn)
+}
+
+func (d *DAWG) walk(s int32, buf []byte, i *int, fn func(key string, v interface{}) bool) bool {
+	st := &d.states[s]
+	if st.final {
+		if !fn(d.tr.order(string(buf)), d.values[*i]) {
+			return false
+		}
+		*i++
+	}
+	for _, t := range d.trans[st.trans : st.trans+st.n] {
+		if !d.walk(t.to, append(buf, t.c), i, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// dawgBuilder builds a DAWG with the incremental algorithm for sorted labels
+// by Daciuk et al., which minimizes states as soon as no more labels can reach them.
+type dawgBuilder struct {
+	nodes     []*dawgNode
+	register  map[string]*dawgNode
+	unchecked []*dawgNode // path to the last label's final state
+	prev      string
+	values    []interface{}
+}
+
+type dawgNode struct {
+	id    int32
+	final bool
+	cs    []byte
+	next  []*dawgNode
+}
+
+func newDAWGBuilder() *dawgBuilder {
+	return &dawgBuilder{
+		register:  make(map[string]*dawgNode),
+		unchecked: []*dawgNode{{}},
+	}
+}
+
+func (db *dawgBuilder) add(label string, v interface{}) {
+	i := 0
+	for i < len(db.prev) && i < len(label) && db.prev[i] == label[i] {
+		i++
+	}
+	db.minimize(i)
+	n := db.unchecked[len(db.unchecked)-1]
+	for j := i; j < len(label); j++ {
+		c := &dawgNode{}
+		n.cs = append(n.cs, label[j])
+		n.next = append(n.next, c)
+		db.unchecked = append(db.unchecked, c)
+		n = c
+	}
+	n.final = true
+	db.prev = label
+	db.values = append(db.values, v)
+}
+
+// minimize replaces nodes deeper than depth by equivalent registered nodes.
+func (db *dawgBuilder) minimize(depth int) {
+	for len(db.unchecked)-1 > depth {
+		c := db.unchecked[len(db.unchecked)-1]
+		db.unchecked = db.unchecked[:len(db.unchecked)-1]
+		p := db.unchecked[len(db.unchecked)-1]
+		sig := c.signature()
+		if r, ok := db.register[sig]; ok {
+			p.next[len(p.next)-1] = r
+			continue
+		}
+		c.id = int32(len(db.nodes)) + 1 // the root is 0
+		db.nodes = append(db.nodes, c)
+		db.register[sig] = c
+	}
+}
+
+// signature identifies nodes by their transitions, whose nodes are already minimized.
+func (n *dawgNode) signature() string {
+	var bd strings.Builder
+	if n.final {
+		bd.WriteByte(1)
+	} else {
+		bd.WriteByte(0)
+	}
+	var b [5]byte
+	for i, c := range n.cs {
+		b[0] = c
+		binary.LittleEndian.PutUint32(b[1:], uint32(n.next[i].id))
+		bd.Write(b[:])
+	}
+	return bd.String()
+}
+
+func (db *dawgBuilder) build() *DAWG {
+	db.minimize(0)
+	d := &DAWG{
+		states: make([]state, len(db.nodes)+1),
+		values: db.values,
+	}
+	// Children are registered before their parents, so their counts are ready.
+	for _, n := range append(db.nodes, db.unchecked[0]) {
+		st := &d.states[n.id]
+		st.final = n.final
+		st.trans = int32(len(d.trans))
+		st.n = int32(len(n.cs))
+		if n.final {
+			st.count = 1
+		}
+		for j, c := range n.cs {
+			d.trans = append(d.trans, transition{c: c, to: n.next[j].id})
+			st.count += d.states[n.next[j].id].count
+		}
+	}
+	return d
+}
@@ -0,0 +1,86 @@
+package radix_test
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestDAWG(t *testing.T) {
+	words := []string{
+		"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus",
+		"test", "tests", "tested", "testing", "toast", "toasts", "toasted", "toasting",
+		"a", "ab", "abc", "b", "áb", "ác", "日本", "日本語", "日月",
+	}
+	for i := 0; i < 500; i++ {
+		words = append(words, strconv.Itoa(i*7919))
+	}
+	testCases := []int{0, Trune, Tfold, Treverse}
+	for _, flags := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(flags)
+			for i, w := range words {
+				tr.Add(w, i)
+			}
+			d := tr.Minimize()
+			if want, got := len(words), d.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			for i, w := range words {
+				if v, ok := d.Get(w); !ok || v != i {
+					t.Fatalf("want %q to be found, got %v", w, v)
+				}
+			}
+			for _, label := range []string{"", "r", "roman", "romanes", "日", "zzz", "tests1", "toastes"} {
+				if _, ok := d.Get(label); ok {
+					t.Errorf("want %q not to be found", label)
+				}
+			}
+			var got []string
+			d.Walk(func(key string, v interface{}) bool {
+				if words[v.(int)] != key {
+					t.Errorf("want %q, got %q", words[v.(int)], key)
+				}
+				got = append(got, key)
+				return true
+			})
+			want := append([]string(nil), words...)
+			sort.Strings(want)
+			if flags&Treverse == 0 && strings.Join(want, ",") != strings.Join(got, ",") {
+				t.Errorf("want %v, got %v", want, got)
+			}
+		})
+	}
+	if d := New(Tbinary).Minimize(); d != nil {
+		t.Error("want binary trees not to be minimized")
+	}
+}
+
+func TestBuildDAWG(t *testing.T) {
+	iterate := func(labels []string) Iterator {
+		i := 0
+		return func() (string, interface{}, bool) {
+			if i == len(labels) {
+				return "", nil, false
+			}
+			i++
+			return labels[i-1], i - 1, true
+		}
+	}
+	words := []string{"tap", "taps", "top", "tops"}
+	d, err := BuildDAWG(iterate(words))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range words {
+		if v, ok := d.Get(w); !ok || v != i {
+			t.Errorf("want %q to be found, got %v", w, v)
+		}
+	}
+	if _, err := BuildDAWG(iterate([]string{"tops", "top"})); err != ErrUnsorted {
+		t.Errorf("want %v, got %v", ErrUnsorted, err)
+	}
+}
@@ -0,0 +1,223 @@
+package radix
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// ChangeType is the type of a change between two trees.
+type ChangeType uint8
+
+const (
+	// AddedChange is the type of labels that are only held by the new tree.
+	AddedChange ChangeType = iota
+	// RemovedChange is the type of labels that are only held by the old tree.
+	RemovedChange
+	// ModifiedChange is the type of labels whose values differ between trees.
+	ModifiedChange
+)
+
+// Change is a difference between two trees.
+type Change struct {
+	Type ChangeType
+	Key  string
+	Old  interface{}
+	New  interface{}
+}
+
+// Diff returns the changes from old to new, sorted by key. Values are compared by eq.
+// If eq is nil, they're compared with ==, which panics for values that are not comparable.
+// Expired values are treated as if they weren't in their trees.
+//
+// Trees with the same flags are compared by walking both of them at once.
+// Since trees never share nodes, not even with their clones, both trees are always walked fully.
+func Diff(old, new *Tree, eq func(a, b interface{}) bool) []Change {
+	if old == new {
+		return nil
+	}
+	defer rlockBoth(old, new)()
+	if eq == nil {
+		eq = func(a, b interface{}) bool { return a == b }
+	}
+	d := differ{eq: eq}
+	if old.expiring || new.expiring {
+		d.now = time.Now().UnixNano()
+	}
+	if !old.compatible(new) {
+		old.walkLabels(old.root, "", func(label string, nbits int, n *Node) {
+			d.compare(n, new.find(new.convert(old, label, nbits, n.key)))
+		})
+		new.walkLabels(new.root, "", func(label string, nbits int, n *Node) {
+			if old.find(old.convert(new, label, nbits, n.key)) == nil {
+				d.compare(nil, n)
+			}
+		})
+	} else if old.binary {
+		d.diffBinary(old.root, new.root)
+	} else {
+		d.diff(old, old.root, new.root)
+	}
+	sort.SliceStable(d.changes, func(i, j int) bool { return d.changes[i].Key < d.changes[j].Key })
+	return d.changes
+}
+
+// FormatDiff renders changes similarly to a unified diff, where each removed value
+// is prefixed by "-" and each added value is prefixed by "+".
+func FormatDiff(changes []Change) string {
+	var bd strings.Builder
+	for _, c := range changes {
+		if c.Type != AddedChange {
+			fmt.Fprintf(&bd, "- %s: %v\n", c.Key, c.Old)
+		}
+		if c.Type != RemovedChange {
+			fmt.Fprintf(&bd, "+ %s: %v\n", c.Key, c.New)
+		}
+	}
+	return bd.String()
+}
+
+// rlockBoth locks both trees for reading, always in the same order.
+func rlockBoth(a, b *Tree) func() {
+	if uintptr(unsafe.Pointer(a)) > uintptr(unsafe.Pointer(b)) {
+		a, b = b, a
+	}
+	if a.safe {
+		a.mu.RLock()
+	}
+	if b.safe {
+		b.mu.RLock()
+	}
+	return func() {
+		if a.safe {
+			a.mu.RUnlock()
+		}
+		if b.safe {
+			b.mu.RUnlock()
+		}
+	}
+}
+
+type differ struct {
+	eq      func(a, b interface{}) bool
+	now     int64 // time at which values are checked for expiry
+	changes []Change
+}
+
+// compare compares the values of two nodes reached by the same label, which may be nil.
+func (d *differ) compare(a, b *Node) {
+	av, bv := d.value(a), d.value(b)
+	switch {
+	case av == nil && bv == nil:
+	case av == nil:
+		d.changes = append(d.changes, Change{Type: AddedChange, Key: b.key, New: bv})
+	case bv == nil:
+		d.changes = append(d.changes, Change{Type: RemovedChange, Key: a.key, Old: av})
+	case !d.eq(av, bv):
+		d.changes = append(d.changes, Change{Type: ModifiedChange, Key: b.key, Old: av, New: bv})
+	}
+}
+
+// value returns n's value, unless n is nil or its value is expired.
+func (d *differ) value(n *Node) interface{} {
+	if n == nil || !n.holds(d.now) {
+		return nil
+	}
+	return n.Value
+}
+
+// all adds every value of n's subtree as a change of type typ.
+func (d *differ) all(n *Node, typ ChangeType) {
+	n.walkAll(func(n *Node) {
+		if !n.holds(d.now) {
+			return
+		}
+		c := Change{Type: typ, Key: n.key}
+		if typ == AddedChange {
+			c.New = n.Value
+		} else {
+			c.Old = n.Value
+		}
+		d.changes = append(d.changes, c)
+	})
+}
+
+// diff compares the subtrees of two nodes reached by the same label.
+func (d *differ) diff(tr *Tree, a, b *Node) {
+	d.compare(a, b)
+	paired := make(map[*edge]bool)
+	for _, ea := range a.edges {
+		var (
+			eb *edge
+			i  int
+		)
+		for _, e := range b.lookup(ea.label[0]) {
+			i = 0
+			for i < len(e.label) && i < len(ea.label) && e.label[i] == ea.label[i] {
+				i++
+			}
+			if i = tr.cut(e.label, ea.label, i); i > 0 {
+				eb = e
+				break
+			}
+		}
+		if eb == nil {
+			d.all(ea.n, RemovedChange)
+			continue
+		}
+		paired[eb] = true
+		d.diff(tr, splitAt(ea, i), splitAt(eb, i))
+	}
+	for _, eb := range b.edges {
+		if !paired[eb] {
+			d.all(eb.n, AddedChange)
+		}
+	}
+}
+
+// splitAt returns the node at the i-th byte of e's label,
+// which is a new node holding the rest of e if i is in the middle of e.
+func splitAt(e *edge, i int) *Node {
+	if i == len(e.label) {
+		return e.n
+	}
+	n := &Node{edges: []*edge{{label: e.label[i:], n: e.n}}}
+	n.reindex()
+	return n
+}
+
+func (d *differ) diffBinary(a, b *Node) {
+	d.compare(a, b)
+	for bit := range a.edges {
+		ea, eb := a.edges[bit], b.edges[bit]
+		switch {
+		case ea == nil && eb == nil:
+			continue
+		case eb == nil:
+			d.all(ea.n, RemovedChange)
+			continue
+		case ea == nil:
+			d.all(eb.n, AddedChange)
+			continue
+		}
+		end := ea.n.depth
+		if eb.n.depth < end {
+			end = eb.n.depth
+		}
+		i := commonBits(ea.label, eb.label, a.depth+1, end)
+		d.diffBinary(splitBinaryAt(ea, i), splitBinaryAt(eb, i))
+	}
+}
+
+// splitBinaryAt returns the node at the i-th bit of e's label,
+// which is a new node holding the rest of e if i is in the middle of e.
+func splitBinaryAt(e *edge, i int) *Node {
+	if i == e.n.depth {
+		return e.n
+	}
+	n := &Node{depth: i, edges: make([]*edge, 2)}
+	n.edges[bitAt(e.label, i)] = e
+	return n
+}
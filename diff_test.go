@@ -0,0 +1,83 @@
+package radix_test
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestDiff(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	var words []string
+	for i := 0; i < 300; i++ {
+		words = append(words, strconv.Itoa(rnd.Intn(100000)))
+	}
+	words = append(words, "romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus",
+		"日本", "日本語", "日月", "áb", "ác", "a", "ab", "abc")
+	testCases := []struct {
+		oldFlags, newFlags int
+	}{
+		{0, 0},
+		{Trune, Trune},
+		{Tbinary, Tbinary},
+		{0, Tfold},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			old, new := New(tc.oldFlags), New(tc.newFlags)
+			olds, news := make(map[string]int), make(map[string]int)
+			for _, w := range words {
+				v := rnd.Intn(2)
+				switch rnd.Intn(4) {
+				case 0:
+					olds[w] = v
+				case 1:
+					news[w] = v
+				default:
+					olds[w] = v
+					news[w] = rnd.Intn(2)
+				}
+			}
+			var want []Change
+			for w, v := range olds {
+				old.Add(w, v)
+				nv, ok := news[w]
+				switch {
+				case !ok:
+					want = append(want, Change{Type: RemovedChange, Key: w, Old: v})
+				case nv != v:
+					want = append(want, Change{Type: ModifiedChange, Key: w, Old: v, New: nv})
+				}
+			}
+			for w, v := range news {
+				new.Add(w, v)
+				if _, ok := olds[w]; !ok {
+					want = append(want, Change{Type: AddedChange, Key: w, New: v})
+				}
+			}
+			sort.Slice(want, func(i, j int) bool { return want[i].Key < want[j].Key })
+			if got := Diff(old, new, nil); !reflect.DeepEqual(want, got) {
+				t.Errorf("want %v, got %v", want, got)
+			}
+			if got := Diff(old, old, nil); len(got) > 0 {
+				t.Errorf("want no changes, got %v", got)
+			}
+		})
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	old, new := New(0), New(0)
+	old.Add("romane", 1)
+	old.Add("romanus", 2)
+	new.Add("romanus", 3)
+	new.Add("romulus", 4)
+	want := "- romane: 1\n- romanus: 2\n+ romanus: 3\n+ romulus: 4\n"
+	if got := FormatDiff(Diff(old, new, nil)); want != got {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
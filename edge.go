@@ -9,7 +9,18 @@ type edge struct {
 	n     *Node
 }
 
-func (e *edge) writeTo(bd *builder, tabList []bool) {
+// merge merges the edge with the only edge of its node.
+func (e *edge) merge() {
+	next := e.n.edges[0]
+	e.label += next.label
+	e.n = next.n
+	e.n.decrDepth()
+}
+
+// writeTo writes the edge and its node's subtree, where path is the label of the edge's parent.
+// Edges of reversed trees are written as the labels they lead to, in their original order,
+// so path is only used for them.
+func (e *edge) writeTo(bd *builder, tabList []bool, path string) {
 	length := len(tabList)
 	isLast, tlist := tabList[length-1], tabList[:length-1]
 	for _, hasTab := range tlist {
@@ -29,7 +40,12 @@ func (e *edge) writeTo(bd *builder, tabList []bool) {
 	if bd.debug {
 		bd.WriteString(bd.colors[colorRed].Wrapf("%d↑ ", e.n.priority))
 	}
-	bd.WriteString(bd.colors[colorBold].Wrap(e.label))
+	label := e.label
+	if bd.reverse {
+		path += e.label
+		label = reverse(path)
+	}
+	bd.WriteString(bd.colors[colorBold].Wrap(label))
 	if bd.debug {
 		if e.n.IsLeaf() {
 			bd.WriteString(bd.colors[colorGreen].Wrap(" 🍂"))
@@ -43,6 +59,6 @@ func (e *edge) writeTo(bd *builder, tabList []bool) {
 		} else {
 			tabList[next.n.depth-1] = i == len(e.n.edges)-1
 		}
-		next.writeTo(bd, tabList)
+		next.writeTo(bd, tabList, path)
 	}
 }
@@ -0,0 +1,327 @@
+package radix
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Layout of flat trees, where integers are little-endian uint32:
+//
+//	header: magic, version, flags, root's offset, number of values
+//	node:   label's length, label, value's offset (0 if none),
+//	        number of edges, first bytes of edges, offsets of edges' nodes
+//	value:  key's length, key, value's length, value
+//
+// Edges are sorted by label, so nodes can be searched without being decoded.
+const (
+	flatMagic   = "rdx\x00"
+	flatVersion = 1
+	flatHeader  = 16
+	flatFold    = 1 // flag for case-insensitive labels
+)
+
+var (
+	// ErrFlatUnsupported is returned when flattening a binary, reversed or
+	// hostname tree, a tree with boundaries set by SetBoundaries, which flat trees
+	// don't match, a tree with a custom normalizer or a tree larger than 4 GiB.
+	ErrFlatUnsupported = errors.New("radix: tree can't be flattened")
+	// ErrFlatInvalid is returned when data is not a flat tree.
+	ErrFlatInvalid = errors.New("radix: invalid flat tree")
+)
+
+// MarshalFlat encodes the tree into a flat layout that holds no pointers,
+// which can be stored in a file and queried with NewFlat without being decoded.
+// Values are encoded by enc, and expired ones are skipped.
+func (tr *Tree) MarshalFlat(enc func(v interface{}) ([]byte, error)) ([]byte, error) {
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	if tr.binary || tr.reverse || tr.host || tr.placeholder != 0 || tr.normalize != nil {
+		return nil, ErrFlatUnsupported
+	}
+	buf := make([]byte, flatHeader, flatHeader+tr.size*2)
+	copy(buf, flatMagic)
+	buf[4] = flatVersion
+	if tr.fold {
+		buf[5] |= flatFold
+	}
+	fw := flatWriter{buf: buf, enc: enc, now: time.Now().UnixNano()}
+	root, err := fw.write(tr.root, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(fw.buf) > math.MaxUint32 {
+		return nil, ErrFlatUnsupported
+	}
+	binary.LittleEndian.PutUint32(fw.buf[8:], root)
+	binary.LittleEndian.PutUint32(fw.buf[12:], uint32(fw.count))
+	return fw.buf, nil
+}
+
+type flatWriter struct {
+	buf   []byte
+	enc   func(v interface{}) ([]byte, error)
+	now   int64
+	count int
+}
+
+func (fw *flatWriter) uint32(n int) {
+	fw.buf = append(fw.buf, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(fw.buf[len(fw.buf)-4:], uint32(n))
+}
+
+// write writes n and its children, returning n's offset.
+func (fw *flatWriter) write(n *Node, label string) (uint32, error) {
+	var voff int
+	if n.Value != nil && !n.expired(fw.now) {
+		v, err := fw.enc(n.Value)
+		if err != nil {
+			return 0, err
+		}
+		voff = len(fw.buf)
+		fw.uint32(len(n.key))
+		fw.buf = append(fw.buf, n.key...)
+		fw.uint32(len(v))
+		fw.buf = append(fw.buf, v...)
+		fw.count++
+	}
+	edges := make([]*edge, len(n.edges))
+	copy(edges, n.edges)
+	sort.Slice(edges, func(i, j int) bool { return edges[i].label < edges[j].label })
+
+	off := len(fw.buf)
+	fw.uint32(len(label))
+	fw.buf = append(fw.buf, label...)
+	fw.uint32(voff)
+	fw.uint32(len(edges))
+	for _, e := range edges {
+		fw.buf = append(fw.buf, e.label[0])
+	}
+	children := len(fw.buf)
+	fw.buf = append(fw.buf, make([]byte, len(edges)*4)...)
+	for i, e := range edges {
+		c, err := fw.write(e.n, e.label)
+		if err != nil {
+			return 0, err
+		}
+		binary.LittleEndian.PutUint32(fw.buf[children+i*4:], c)
+	}
+	return uint32(off), nil
+}
+
+// Flat is a read-only tree encoded by (*Tree).MarshalFlat.
+// Since it is queried directly from its data, which can be memory-mapped,
+// it's safe for concurrent use and only needs to be validated once to be loaded.
+//
+// Keys and values returned by its methods reference its data,
+// so they're only valid while the data is, and must not be modified.
+type Flat struct {
+	data  []byte
+	root  uint32
+	count int
+	fold  bool
+}
+
+// NewFlat returns a flat tree that reads from data, which is not copied.
+//
+// The whole data is validated, so that corrupt or truncated data
+// returns ErrFlatInvalid instead of making queries panic.
+func NewFlat(data []byte) (*Flat, error) {
+	if len(data) < flatHeader || string(data[:4]) != flatMagic || data[4] != flatVersion {
+		return nil, ErrFlatInvalid
+	}
+	ft := &Flat{
+		data:  data,
+		root:  binary.LittleEndian.Uint32(data[8:]),
+		count: int(binary.LittleEndian.Uint32(data[12:])),
+		fold:  data[5]&flatFold > 0,
+	}
+	if !ft.valid() {
+		return nil, ErrFlatInvalid
+	}
+	return ft, nil
+}
+
+// flatNode is the size of the smallest node, which is the root.
+const flatNode = 12
+
+// valid checks that every node and value is within the data.
+// Nodes are written after their parents, so offsets of children
+// must be greater than their parents' ones, which rules out cycles.
+func (ft *Flat) valid() bool {
+	size := len(ft.data)
+	var nodes, count int
+	stack := []int{int(ft.root)}
+	for len(stack) > 0 {
+		off := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if nodes++; nodes > size/flatNode { // some nodes are shared
+			return false
+		}
+		if off < flatHeader || off > size-flatNode {
+			return false
+		}
+		n := int(ft.uint32(off))
+		if n > size-flatNode-off {
+			return false
+		}
+		i := off + 4 + n
+		if voff := int(ft.uint32(i)); voff != 0 {
+			if !ft.validValue(voff) {
+				return false
+			}
+			count++
+		}
+		n = int(ft.uint32(i + 4))
+		i += 8
+		if n > (size-i)/5 {
+			return false
+		}
+		firsts, children := i, i+n
+		for j := 0; j < n; j++ {
+			c := int(ft.uint32(children + j*4))
+			// Labels of children are never empty and start with their first bytes.
+			if c <= off || c > size-flatNode-1 || ft.uint32(c) == 0 || ft.data[c+4] != ft.data[firsts+j] {
+				return false
+			}
+			stack = append(stack, c)
+		}
+	}
+	return count == ft.count
+}
+
+// validValue checks that the value at off is within the data.
+func (ft *Flat) validValue(off int) bool {
+	size := len(ft.data)
+	if off < flatHeader || off > size-8 {
+		return false
+	}
+	n := int(ft.uint32(off))
+	if n > size-8-off {
+		return false
+	}
+	off += 4 + n
+	return int(ft.uint32(off)) <= size-4-off
+}
+
+// Get retrieves the value of label.
+func (ft *Flat) Get(label string) ([]byte, bool) {
+	if label == "" {
+		return nil, false
+	}
+	off := ft.root
+	for label = ft.key(label); label != ""; {
+		c, ok := ft.next(off, label)
+		if !ok {
+			return nil, false
+		}
+		label = label[len(ft.label(c)):]
+		off = c
+	}
+	_, v, ok := ft.value(off)
+	return v, ok
+}
+
+// LongestPrefix retrieves the key and value of the longest label that is a prefix of label.
+func (ft *Flat) LongestPrefix(label string) (string, []byte, bool) {
+	var (
+		key   string
+		value []byte
+		found bool
+	)
+	off := ft.root
+	for label = ft.key(label); label != ""; {
+		c, ok := ft.next(off, label)
+		if !ok {
+			break
+		}
+		label = label[len(ft.label(c)):]
+		off = c
+		if k, v, ok := ft.value(off); ok {
+			key, value, found = k, v, true
+		}
+	}
+	return key, value, found
+}
+
+// Len returns the number of values in the tree.
+func (ft *Flat) Len() int {
+	return ft.count
+}
+
+// Walk calls fn for every key and value in ascending order of labels.
+// If fn returns false, walking stops.
+func (ft *Flat) Walk(fn func(key string, value []byte) bool) {
+	ft.walk(ft.root, fn)
+}
+
+func (ft *Flat) walk(off uint32, fn func(key string, value []byte) bool) bool {
+	if k, v, ok := ft.value(off); ok && !fn(k, v) {
+		return false
+	}
+	firsts, children := ft.edges(off)
+	for i := range firsts {
+		if !ft.walk(ft.uint32(children+i*4), fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func (ft *Flat) key(label string) string {
+	if ft.fold {
+		return fold(label)
+	}
+	return label
+}
+
+func (ft *Flat) uint32(off int) uint32 {
+	return binary.LittleEndian.Uint32(ft.data[off:])
+}
+
+// label returns the label of the edge that leads to the node at off.
+func (ft *Flat) label(off uint32) string {
+	n := ft.uint32(int(off))
+	return b2s(ft.data[off+4 : off+4+n])
+}
+
+// value returns the key and value of the node at off.
+func (ft *Flat) value(off uint32) (string, []byte, bool) {
+	voff := int(ft.uint32(int(off) + 4 + len(ft.label(off))))
+	if voff == 0 {
+		return "", nil, false
+	}
+	n := int(ft.uint32(voff))
+	key := b2s(ft.data[voff+4 : voff+4+n])
+	voff += 4 + n
+	n = int(ft.uint32(voff))
+	return key, ft.data[voff+4 : voff+4+n], true
+}
+
+// edges returns the first bytes of the edges of the node at off
+// and the offset of the offsets of their nodes.
+func (ft *Flat) edges(off uint32) (string, int) {
+	i := int(off) + 4 + len(ft.label(off)) + 4
+	n := int(ft.uint32(i))
+	i += 4
+	return b2s(ft.data[i : i+n]), i + n
+}
+
+// next returns the offset of the node whose edge's label is a prefix of label.
+func (ft *Flat) next(off uint32, label string) (uint32, bool) {
+	firsts, children := ft.edges(off)
+	c := label[0]
+	// Edges whose labels start with the same byte are adjacent.
+	for i := sort.Search(len(firsts), func(i int) bool { return firsts[i] >= c }); i < len(firsts) && firsts[i] == c; i++ {
+		child := ft.uint32(children + i*4)
+		if strings.HasPrefix(label, ft.label(child)) {
+			return child, true
+		}
+	}
+	return 0, false
+}
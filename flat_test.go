@@ -0,0 +1,153 @@
+package radix_test
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func encodeInt(v interface{}) ([]byte, error) {
+	return []byte(strconv.Itoa(v.(int))), nil
+}
+
+func TestFlat(t *testing.T) {
+	words := []string{
+		"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus",
+		"test", "toaster", "toasting", "slow", "slowly", "tom", "tomato", "tornado",
+		"a", "ab", "abc", "b", "áb", "ác", "日本", "日本語", "日月",
+	}
+	for i := 0; i < 300; i++ {
+		words = append(words, strconv.Itoa(i*7919))
+	}
+	testCases := []int{0, Trune, Tfold}
+	for _, flags := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(flags)
+			for i, w := range words {
+				tr.Add(w, i)
+			}
+			tr.Sort(PrioritySort)
+			data, err := tr.MarshalFlat(encodeInt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ft, err := NewFlat(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, got := len(words), ft.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			for i, w := range words {
+				if v, ok := ft.Get(w); !ok || string(v) != strconv.Itoa(i) {
+					t.Errorf("want %q to be found", w)
+				}
+			}
+			if _, ok := ft.Get("ROMANE"); ok != (flags&Tfold > 0) {
+				t.Errorf("want %t, got %t", flags&Tfold > 0, ok)
+			}
+			for _, label := range []string{"", "r", "roman", "romanes", "日", "zzz", "tomatoes", "romanusx"} {
+				n := tr.LongestPrefix(label)
+				key, v, ok := ft.LongestPrefix(label)
+				if want, got := n != nil, ok; want != got {
+					t.Fatalf("want %t, got %t", want, got)
+				}
+				if n != nil && (n.Key() != key || fmt.Sprint(n.Value) != string(v)) {
+					t.Errorf("want %q, got %q", n.Key(), key)
+				}
+				if n, _ := tr.Get(label); n == nil {
+					if _, ok := ft.Get(label); ok {
+						t.Errorf("want %q not to be found", label)
+					}
+				}
+			}
+			var keys []string
+			ft.Walk(func(key string, v []byte) bool {
+				keys = append(keys, key)
+				return true
+			})
+			if want, got := len(words), len(keys); want != got {
+				t.Fatalf("want %d, got %d", want, got)
+			}
+			if !sort.StringsAreSorted(keys) {
+				t.Errorf("want keys to be sorted, got %v", keys)
+			}
+			if allocs := testing.AllocsPerRun(100, func() { ft.Get("rubicundus") }); allocs != 0 {
+				t.Errorf("want no allocations, got %.0f", allocs)
+			}
+		})
+	}
+}
+
+func TestFlatErrors(t *testing.T) {
+	for _, flags := range []int{Tbinary, Treverse, Thost} {
+		if _, err := New(flags).MarshalFlat(encodeInt); err != ErrFlatUnsupported {
+			t.Errorf("want %v, got %v", ErrFlatUnsupported, err)
+		}
+	}
+	tr := New(0)
+	tr.SetBoundaries('@', '/')
+	tr.Add("/users/@id", 1)
+	if _, err := tr.MarshalFlat(encodeInt); err != ErrFlatUnsupported {
+		t.Errorf("want %v, got %v", ErrFlatUnsupported, err)
+	}
+	for _, data := range [][]byte{nil, []byte("radix tree"), make([]byte, 32)} {
+		if _, err := NewFlat(data); err != ErrFlatInvalid {
+			t.Errorf("want %v, got %v", ErrFlatInvalid, err)
+		}
+	}
+
+	tr = New(0)
+	for i, w := range []string{"romane", "romanus", "romulus", "rubens", "ruber"} {
+		tr.Add(w, i)
+	}
+	data, err := tr.MarshalFlat(encodeInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Truncated data is invalid.
+	for i := range data {
+		if _, err := NewFlat(data[:i]); err != ErrFlatInvalid {
+			t.Fatalf("want %v for %d bytes, got %v", ErrFlatInvalid, i, err)
+		}
+	}
+	// Corrupt data is either invalid or safe to query.
+	for i := 4; i < len(data); i++ {
+		corrupt := append([]byte(nil), data...)
+		for _, b := range []byte{0x00, 0x01, 0x7F, 0xFF} {
+			corrupt[i] = b
+			ft, err := NewFlat(corrupt)
+			if err != nil {
+				continue
+			}
+			ft.Get("romanus")
+			ft.LongestPrefix("rubicon")
+			ft.Walk(func(string, []byte) bool { return true })
+		}
+	}
+}
+
+func TestFlatExpired(t *testing.T) {
+	tr := New(0)
+	tr.Add("romane", 1)
+	tr.AddWithTTL("romanus", 2, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	data, err := tr.MarshalFlat(encodeInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ft, err := NewFlat(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, ft.Len(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if _, ok := ft.Get("romanus"); ok {
+		t.Errorf("want %q not to be found", "romanus")
+	}
+}
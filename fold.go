@@ -0,0 +1,90 @@
+package radix
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// fold case folds s so that strings that are equal under
+// Unicode simple case folding end up being byte-equal.
+// Invalid UTF-8 bytes are kept untouched.
+func fold(s string) string {
+	var i int
+	for i < len(s) {
+		c := s[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r != utf8.RuneError && foldRune(r) != r {
+				break
+			}
+			i += size
+			continue
+		}
+		if 'A' <= c && c <= 'Z' {
+			break
+		}
+		i++
+	}
+	if i == len(s) { // nothing to fold, so avoid allocating
+		return s
+	}
+	var bd strings.Builder
+	bd.Grow(len(s))
+	bd.WriteString(s[:i])
+	for i < len(s) {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			bd.WriteByte(c)
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError {
+			bd.WriteString(s[i : i+size])
+		} else {
+			bd.WriteRune(foldRune(r))
+		}
+		i += size
+	}
+	return bd.String()
+}
+
+// foldRune maps r to a single representative of its orbit under Unicode simple case folding,
+// which is the orbit's smallest lowercase rune or, if it has none, its smallest rune.
+func foldRune(r rune) rune {
+	rep := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if lower := unicode.IsLower(f); lower && (!unicode.IsLower(rep) || f < rep) ||
+			!lower && !unicode.IsLower(rep) && f < rep {
+			rep = f
+		}
+	}
+	return rep
+}
+
+// foldOffsets maps each byte of the case folded s, and its end,
+// to the offset of the rune of s it was folded from.
+func foldOffsets(s string) []int {
+	offs := make([]int, 0, len(s)+1)
+	for i := 0; i < len(s); {
+		if s[i] < utf8.RuneSelf {
+			offs = append(offs, i)
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		n := size
+		if r != utf8.RuneError {
+			n = utf8.RuneLen(foldRune(r))
+		}
+		for j := 0; j < n; j++ {
+			offs = append(offs, i)
+		}
+		i += size
+	}
+	return append(offs, len(s))
+}
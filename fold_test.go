@@ -0,0 +1,110 @@
+package radix_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestFold(t *testing.T) {
+	testCases := []struct {
+		labels      []string
+		lookups     []string
+		params      map[string]string
+		placeholder byte
+		delim       byte
+		normalize   func(string) string
+	}{
+		{
+			labels:  []string{"Example.COM", "example.org"},
+			lookups: []string{"example.com", "EXAMPLE.ORG"},
+		},
+		{
+			labels:  []string{"Straße", "ΣΊΣΥΦΟΣ"},
+			lookups: []string{"STRAẞE", "σίσυφος"},
+		},
+		{
+			labels:  []string{"Kelvin"},
+			lookups: []string{"KELVIN"}, // Kelvin sign
+		},
+		{
+			labels:      []string{"/Users/@ID"},
+			lookups:     []string{"/users/AbC"},
+			params:      map[string]string{"ID": "AbC"},
+			placeholder: '@',
+			delim:       '/',
+		},
+		{
+			labels:      []string{"/@First/@Second"},
+			lookups:     []string{"/\u212a/\u023a\u023a"}, // Kelvin sign shrinks and "Ⱥ" grows when folded
+			params:      map[string]string{"First": "\u212a", "Second": "\u023a\u023a"},
+			placeholder: '@',
+			delim:       '/',
+		},
+		{
+			labels:    []string{"foo-bar"},
+			lookups:   []string{"FOO_BAR"},
+			normalize: func(s string) string { return strings.Replace(s, "_", "-", -1) },
+		},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(Tdebug | Tfold)
+			if tc.placeholder != 0 && tc.delim != 0 {
+				tr.SetBoundaries(tc.placeholder, tc.delim)
+			}
+			tr.SetNormalizer(tc.normalize)
+			for i, label := range tc.labels {
+				tr.Add(label, i)
+			}
+			t.Log(tr.String())
+
+			var p map[string]string
+			for i, label := range tc.labels {
+				var n *Node
+				n, p = tr.Get(tc.lookups[i])
+				if n == nil {
+					t.Fatalf("want %q to match %q", tc.lookups[i], label)
+				}
+				if want, got := i, n.Value; want != got {
+					t.Errorf("want %v, got %v", want, got)
+				}
+				if want, got := label, n.Key(); want != got {
+					t.Errorf("want %q, got %q", want, got)
+				}
+			}
+			if want, got := tc.params, p; !reflect.DeepEqual(want, got) {
+				t.Errorf("want %v, got %v", want, got)
+			}
+
+			var keys []string
+			tr.Walk(func(key string, _ *Node) bool {
+				keys = append(keys, key)
+				return true
+			})
+			if want, got := tc.labels, keys; !reflect.DeepEqual(want, got) {
+				t.Errorf("want %v, got %v", want, got)
+			}
+
+			for i, label := range tc.labels {
+				tr.Del(strings.ToUpper(label))
+				if n, _ := tr.Get(tc.lookups[i]); n != nil {
+					t.Errorf("want %q to be deleted", label)
+				}
+			}
+		})
+	}
+
+	// Names of named labels of hostnames also keep their case.
+	tr := New(Thost | Tfold)
+	tr.Add("@Tenant.Example.com", 1)
+	n, p := tr.Get("ACME.example.COM")
+	if n == nil {
+		t.Fatalf("want %q to match %q", "ACME.example.COM", "@Tenant.Example.com")
+	}
+	if want, got := map[string]string{"Tenant": "ACME"}, p; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/gbrlsnchs/radix
 
+go 1.18
+
 require github.com/gbrlsnchs/color v0.1.0
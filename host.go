@@ -0,0 +1,127 @@
+package radix
+
+import "strings"
+
+type param struct {
+	key   string
+	value string
+	off   int // offset of the value in the label being matched
+}
+
+// reverseHost reverses the order of the labels of a hostname, so that
+// "api.example.com" becomes "com.example.api". A trailing delimiter,
+// which denotes a fully qualified domain name, is trimmed.
+func reverseHost(s string, delim byte) string {
+	if len(s) > 1 && s[len(s)-1] == delim {
+		s = s[:len(s)-1]
+	}
+	if strings.IndexByte(s, delim) < 0 {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for {
+		i := strings.LastIndexByte(s, delim)
+		if i < 0 {
+			return string(append(b, s...))
+		}
+		b = append(b, s[i+1:]...)
+		b = append(b, delim)
+		s = s[:i]
+	}
+}
+
+// isDelimited returns whether s can be split at i without
+// breaking any of the labels of a hostname.
+func (tr *Tree) isDelimited(s string, i int) bool {
+	return i >= len(s) || s[i] == tr.delim || s[i-1] == tr.delim
+}
+
+// getHost matches label against the hostname patterns stored in the tree.
+//
+// Labels are matched from the top-level domain on and, for each one of them,
+// static labels are preferred over named labels, which in turn are preferred over wildcards.
+// If a pattern doesn't match the remaining labels, the next most specific one is tried.
+func (tr *Tree) getHost(label, orig string, now int64) (*Node, map[string]string) {
+	var buf [4]param
+	n, ps := tr.matchHost(tr.root, label, len(label), now, buf[:0])
+	return n, tr.params(n, ps, label, orig)
+}
+
+const (
+	hostStatic = iota
+	hostParam
+	hostWildcard
+)
+
+func (tr *Tree) hostKind(label string) int {
+	switch {
+	case label[0] == tr.placeholder:
+		return hostParam
+	case label[0] == '*' && (len(label) == 1 || label[1] == tr.delim):
+		return hostWildcard
+	}
+	return hostStatic
+}
+
+// matchHost returns the most specific pattern below n that matches label
+// and holds a value that isn't expired at now.
+func (tr *Tree) matchHost(n *Node, label string, size int, now int64, ps []param) (*Node, []param) {
+	if label == "" {
+		if !n.holds(now) {
+			return nil, ps
+		}
+		return n, ps
+	}
+	for kind := hostStatic; kind <= hostWildcard; kind++ {
+		for _, e := range n.edges {
+			if tr.hostKind(e.label) != kind {
+				continue
+			}
+			rest, matched, ok := tr.matchHostEdge(e.label, label, size, ps)
+			if !ok {
+				continue
+			}
+			if m, mps := tr.matchHost(e.n, rest, size, now, matched); m != nil {
+				return m, mps
+			}
+		}
+	}
+	return nil, ps
+}
+
+// matchHostEdge matches an edge's label against the beginning of label,
+// one hostname label at a time.
+func (tr *Tree) matchHostEdge(slice, label string, size int, ps []param) (string, []param, bool) {
+	for slice != "" {
+		if label == "" {
+			return label, ps, false
+		}
+		if slice[0] == tr.delim {
+			if label[0] != tr.delim {
+				return label, ps, false
+			}
+			slice, label = slice[1:], label[1:]
+			continue
+		}
+		i := strings.IndexByte(slice, tr.delim)
+		if i < 0 {
+			i = len(slice)
+		}
+		j := strings.IndexByte(label, tr.delim)
+		if j < 0 {
+			j = len(label)
+		}
+		if j == 0 { // empty hostname label
+			return label, ps, false
+		}
+		switch seg := slice[:i]; {
+		case seg[0] == tr.placeholder:
+			ps = append(ps, param{key: seg[1:], value: label[:j], off: size - len(label)})
+		case seg == "*":
+		case seg != label[:j]:
+			return label, ps, false
+		}
+		slice, label = slice[i:], label[j:]
+	}
+	return label, ps, true
+}
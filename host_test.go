@@ -0,0 +1,94 @@
+package radix_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestHost(t *testing.T) {
+	patterns := []string{
+		"example.com",
+		"*.example.com",
+		"@tenant.example.com",
+		"api.example.com",
+		"*.api.example.com",
+		"@region.@tenant.example.com",
+		"www.example.org",
+	}
+	testCases := []struct {
+		host   string
+		flags  int
+		key    string
+		params map[string]string
+	}{
+		{host: "example.com", key: "example.com"},
+		{host: "example.com.", key: "example.com"},
+		{host: "api.example.com", key: "api.example.com"},
+		{host: "acme.example.com", key: "@tenant.example.com", params: map[string]string{"tenant": "acme"}},
+		{host: "v1.api.example.com", key: "*.api.example.com"},
+		{
+			host:   "eu.acme.example.com",
+			key:    "@region.@tenant.example.com",
+			params: map[string]string{"region": "eu", "tenant": "acme"},
+		},
+		{host: "www.example.org", key: "www.example.org"},
+		{host: "x.eu.acme.example.com"},
+		{host: "example.org"},
+		{host: "xample.com"},
+		{host: "examples.com"},
+		{host: "com"},
+		{host: ".example.com"},
+		{host: "API.Example.COM", flags: Tfold, key: "api.example.com"},
+		{
+			host:   "ACME.example.com",
+			flags:  Tfold,
+			key:    "@tenant.example.com",
+			params: map[string]string{"tenant": "ACME"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.host, func(t *testing.T) {
+			tr := New(Tdebug | Thost | tc.flags)
+			for _, p := range patterns {
+				tr.Add(p, p)
+			}
+			t.Log(tr.String())
+
+			var key string
+			n, p := tr.Get(tc.host)
+			if n != nil {
+				key = n.Key()
+			}
+			if want, got := tc.key, key; want != got {
+				t.Errorf("want %q, got %q", want, got)
+			}
+			if want, got := tc.params, p; !reflect.DeepEqual(want, got) {
+				t.Errorf("want %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestHostDel(t *testing.T) {
+	tr := New(Thost)
+	patterns := []string{"example.com", "examples.com", "*.example.com", "api.example.com"}
+	for _, p := range patterns {
+		tr.Add(p, p)
+	}
+	for i, p := range patterns {
+		tr.Del(p)
+		if n, _ := tr.Get(p); n != nil {
+			t.Errorf("want %q to be deleted", p)
+		}
+		for _, p := range patterns[i+1:] {
+			if n, _ := tr.Get(p); n == nil || n.Key() != p {
+				t.Errorf("want %q to be kept", p)
+			}
+		}
+	}
+	if want, got := 1, tr.Len(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
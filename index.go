@@ -0,0 +1,158 @@
+package radix
+
+import "strings"
+
+// Maximum number of edges for each kind of index, which are similar
+// to the node types of an adaptive radix tree (ART).
+const (
+	node16 = 16 // first bytes are searched in the node's indices
+	node48 = 48 // first bytes map to edges' positions
+	// More than 48 edges: first bytes map directly to edges.
+)
+
+// index maps the first bytes of the labels of a node's edges to the edges.
+//
+// Nodes whose edges share first bytes, which can happen when edges
+// are split on rune or hostname boundaries, only use their indices.
+type index struct {
+	slots *[256]uint8 // positions of edges plus one
+	table *[256]*edge
+}
+
+// set indexes e, which is in the i-th position. If e's first byte
+// is already indexed, it does nothing and returns false.
+func (idx *index) set(e *edge, i int) bool {
+	c := e.label[0]
+	if idx.table != nil {
+		if idx.table[c] != nil {
+			return false
+		}
+		idx.table[c] = e
+		return true
+	}
+	if idx.slots[c] > 0 {
+		return false
+	}
+	idx.slots[c] = uint8(i + 1)
+	return true
+}
+
+// addEdge appends e to the node's edges, growing the node's index if needed.
+func (n *Node) addEdge(e *edge) {
+	n.edges = append(n.edges, e)
+	n.dups = n.dups || strings.IndexByte(n.indices, e.label[0]) >= 0
+	n.indices += e.label[:1]
+	length := len(n.edges)
+	if length <= node16 {
+		return
+	}
+	if n.idx != nil && (n.idx.table != nil || length <= node48) && n.idx.set(e, length-1) {
+		return
+	}
+	if n.dups && n.idx == nil {
+		return
+	}
+	n.reindex()
+}
+
+// delEdge removes e from the node's edges, updating the node's index in place.
+// Indices are only dropped once a node has too few edges to need them.
+func (n *Node) delEdge(e *edge) {
+	i := 0
+	for i < len(n.edges) && n.edges[i] != e {
+		i++
+	}
+	if i == len(n.edges) {
+		return
+	}
+	copy(n.edges[i:], n.edges[i+1:])
+	n.edges[len(n.edges)-1] = nil
+	n.edges = n.edges[:len(n.edges)-1]
+	n.indices = n.indices[:i] + n.indices[i+1:]
+	wasDup := n.dups
+	n.dups = n.dups && hasDups(n.indices)
+	length := len(n.edges)
+	switch idx := n.idx; {
+	case length <= node16:
+		n.idx = nil
+	case idx == nil:
+		if wasDup && !n.dups {
+			n.buildIndex()
+		}
+	case idx.table != nil:
+		idx.table[e.label[0]] = nil
+	default:
+		idx.slots[e.label[0]] = 0
+		for j := i; j < length; j++ {
+			idx.slots[n.edges[j].label[0]] = uint8(j + 1)
+		}
+	}
+}
+
+// lookup returns the edges that may start with c.
+// When edges share first bytes, edges in between may not start with c.
+func (n *Node) lookup(c byte) []*edge {
+	if idx := n.idx; idx != nil {
+		if idx.table != nil {
+			if idx.table[c] == nil {
+				return nil
+			}
+			return idx.table[c : int(c)+1]
+		}
+		if i := idx.slots[c]; i > 0 {
+			return n.edges[i-1 : i]
+		}
+		return nil
+	}
+	i := strings.IndexByte(n.indices, c)
+	if i < 0 {
+		return nil
+	}
+	if !n.dups {
+		return n.edges[i : i+1]
+	}
+	return n.edges[i : strings.LastIndexByte(n.indices, c)+1]
+}
+
+// reindex rebuilds the node's indices and, for nodes
+// with many edges, also the node's index.
+func (n *Node) reindex() {
+	b := make([]byte, len(n.edges))
+	for i, e := range n.edges {
+		b[i] = e.label[0]
+	}
+	n.indices = string(b)
+	n.dups = hasDups(n.indices)
+	n.idx = nil
+	if len(n.edges) > node16 && !n.dups {
+		n.buildIndex()
+	}
+}
+
+// buildIndex builds the index of a node whose edges don't share first bytes.
+func (n *Node) buildIndex() {
+	idx := &index{}
+	if len(n.edges) <= node48 {
+		idx.slots = &[256]uint8{}
+	} else {
+		idx.table = &[256]*edge{}
+	}
+	for i, e := range n.edges {
+		if !idx.set(e, i) {
+			return
+		}
+	}
+	n.idx = idx
+}
+
+// hasDups returns whether s has any repeated bytes.
+func hasDups(s string) bool {
+	var seen [256]bool
+	for i := 0; i < len(s); i++ {
+		if seen[s[i]] {
+			return true
+		}
+		seen[s[i]] = true
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package radix_test
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestIndex(t *testing.T) {
+	testCases := []int{3, 4, 5, 16, 17, 48, 49, 256}
+	for _, size := range testCases {
+		t.Run("", func(t *testing.T) {
+			labels := make([]string, size)
+			for i, c := range rand.Perm(256)[:size] {
+				labels[i] = string([]byte{byte(c), 'x'})
+			}
+			tr := New(0)
+			for i, label := range labels {
+				tr.Add(label, i)
+				tr.Add(label+"yz", -i) // create a child, so that the edge's node changes
+			}
+			check := func(labels []string) {
+				t.Helper()
+				for _, label := range labels {
+					if n, _ := tr.Get(label); n == nil || n.Key() != label {
+						t.Fatalf("want %q to be found", label)
+					}
+					if n, _ := tr.Get(label + "yz"); n == nil || n.Key() != label+"yz" {
+						t.Fatalf("want %q to be found", label+"yz")
+					}
+				}
+			}
+			check(labels)
+			tr.Sort(PrioritySort)
+			check(labels)
+			tr.Sort(DescLabelSort)
+			check(labels)
+
+			// Shrink the root node.
+			for _, label := range labels[:size/2] {
+				tr.Del(label + "yz")
+				tr.Del(label)
+				if n, _ := tr.Get(label); n != nil {
+					t.Fatalf("want %q to be deleted", label)
+				}
+			}
+			check(labels[size/2:])
+			if want, got := 1+(size-size/2)*2, tr.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+
+			// Grow it back.
+			for i, label := range labels[:size/2] {
+				tr.Add(label, i)
+				tr.Add(label+"yz", -i)
+			}
+			check(labels)
+		})
+	}
+}
+
+func TestIndexDups(t *testing.T) {
+	tr := New(Trune)
+	var labels []string
+	for i := 0; i < 300; i++ {
+		label := string(rune(0x4E00 + i*7)) // most of them start with the same byte
+		labels = append(labels, label)
+		tr.Add(label, i)
+	}
+	tr.Add("x", -1)
+	for i, label := range labels {
+		if n, _ := tr.Get(label); n == nil || n.Value != i {
+			t.Fatalf("want %q to be found", label)
+		}
+	}
+	for _, label := range labels[:250] {
+		tr.Del(label)
+	}
+	for i, label := range labels[250:] {
+		if n, _ := tr.Get(label); n == nil || n.Value != 250+i {
+			t.Fatalf("want %q to be found", label)
+		}
+	}
+	if n, _ := tr.Get("x"); n == nil {
+		t.Errorf("want %q to be found", "x")
+	}
+}
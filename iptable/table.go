@@ -0,0 +1,110 @@
+// Package iptable implements an IP prefix table on top of a binary radix tree.
+//
+// Prefixes are stored bit by bit, so looking up an address
+// returns the most specific prefix that contains it.
+package iptable
+
+import (
+	"net/netip"
+
+	"github.com/gbrlsnchs/radix"
+)
+
+// Table is an IP prefix table that holds both IPv4 and IPv6 prefixes.
+type Table struct {
+	v4 *radix.Tree
+	v6 *radix.Tree
+}
+
+// New creates an empty table. Flags are passed to the underlying radix trees,
+// which are always binary ones.
+func New(flags int) *Table {
+	flags |= radix.Tbinary
+	return &Table{
+		v4: radix.New(flags),
+		v6: radix.New(flags),
+	}
+}
+
+// Insert adds a prefix to the table. Bits of the prefix's address
+// that are not part of the prefix are ignored.
+//
+// IPv4-mapped IPv6 prefixes that are at least 96 bits long
+// are stored as IPv4 prefixes, just like addresses are looked up.
+func (t *Table) Insert(p netip.Prefix, v interface{}) {
+	if !p.IsValid() {
+		return
+	}
+	p = unmap(p)
+	addr := p.Addr()
+	t.tree(addr).AddBits(addr.AsSlice(), p.Bits(), v)
+}
+
+// Delete deletes a prefix from the table.
+func (t *Table) Delete(p netip.Prefix) {
+	if !p.IsValid() {
+		return
+	}
+	p = unmap(p)
+	addr := p.Addr()
+	t.tree(addr).DelBits(addr.AsSlice(), p.Bits())
+}
+
+// Lookup retrieves the most specific prefix that contains addr.
+// IPv4-mapped IPv6 addresses are looked up as IPv4 addresses.
+func (t *Table) Lookup(addr netip.Addr) (netip.Prefix, interface{}, bool) {
+	if !addr.IsValid() {
+		return netip.Prefix{}, nil, false
+	}
+	addr = addr.Unmap()
+	n := t.tree(addr).LongestPrefixBits(addr.AsSlice(), addr.BitLen())
+	if n == nil {
+		return netip.Prefix{}, nil, false
+	}
+	p, _ := addr.Prefix(n.Depth())
+	return p, n.Value, true
+}
+
+// Contains returns whether addr is contained by any of the table's prefixes.
+func (t *Table) Contains(addr netip.Addr) bool {
+	_, _, ok := t.Lookup(addr)
+	return ok
+}
+
+// Walk walks the table calling fn for every prefix in it.
+// If fn returns false, walking stops.
+//
+// IPv4 prefixes are visited before IPv6 ones. Prefixes are visited in
+// address order and, for the same address, shorter prefixes come first.
+func (t *Table) Walk(fn func(p netip.Prefix, v interface{}) bool) {
+	var done bool
+	t.v4.Walk(func(key string, n *radix.Node) bool {
+		var b [4]byte
+		copy(b[:], key)
+		done = !fn(netip.PrefixFrom(netip.AddrFrom4(b), n.Depth()), n.Value)
+		return !done
+	})
+	if done {
+		return
+	}
+	t.v6.Walk(func(key string, n *radix.Node) bool {
+		var b [16]byte
+		copy(b[:], key)
+		return fn(netip.PrefixFrom(netip.AddrFrom16(b), n.Depth()), n.Value)
+	})
+}
+
+// unmap converts an IPv4-mapped IPv6 prefix into an IPv4 one, if it only holds IPv4 addresses.
+func unmap(p netip.Prefix) netip.Prefix {
+	if addr := p.Addr(); addr.Is4In6() && p.Bits() >= 96 {
+		return netip.PrefixFrom(addr.Unmap(), p.Bits()-96)
+	}
+	return p
+}
+
+func (t *Table) tree(addr netip.Addr) *radix.Tree {
+	if addr.Is4() {
+		return t.v4
+	}
+	return t.v6
+}
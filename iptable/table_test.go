@@ -0,0 +1,100 @@
+package iptable_test
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix/iptable"
+)
+
+func TestTable(t *testing.T) {
+	prefixes := []string{
+		"0.0.0.0/0",
+		"10.0.0.0/8",
+		"10.1.0.0/16",
+		"10.1.2.0/24",
+		"10.1.2.3/32",
+		"192.168.0.0/23",
+		"2001:db8::/32",
+		"2001:db8:1::/48",
+	}
+	testCases := []struct {
+		addr   string
+		prefix string
+	}{
+		{addr: "10.1.2.3", prefix: "10.1.2.3/32"},
+		{addr: "10.1.2.4", prefix: "10.1.2.0/24"},
+		{addr: "10.1.3.1", prefix: "10.1.0.0/16"},
+		{addr: "10.2.0.1", prefix: "10.0.0.0/8"},
+		{addr: "192.168.1.255", prefix: "192.168.0.0/23"},
+		{addr: "192.168.2.1", prefix: "0.0.0.0/0"},
+		{addr: "::ffff:10.1.2.4", prefix: "10.1.2.0/24"},
+		{addr: "2001:db8:1::1", prefix: "2001:db8:1::/48"},
+		{addr: "2001:db8:2::1", prefix: "2001:db8::/32"},
+		{addr: "2001:db9::1"},
+	}
+	tb := New(0)
+	for i, s := range prefixes {
+		tb.Insert(netip.MustParsePrefix(s), i)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.addr, func(t *testing.T) {
+			addr := netip.MustParseAddr(tc.addr)
+			p, v, ok := tb.Lookup(addr)
+			if want, got := tc.prefix != "", ok; want != got {
+				t.Fatalf("want %t, got %t", want, got)
+			}
+			if want, got := ok, tb.Contains(addr); want != got {
+				t.Errorf("want %t, got %t", want, got)
+			}
+			if !ok {
+				return
+			}
+			if want, got := netip.MustParsePrefix(tc.prefix), p; want != got {
+				t.Errorf("want %v, got %v", want, got)
+			}
+			var i int
+			for prefixes[i] != tc.prefix {
+				i++
+			}
+			if want, got := i, v; want != got {
+				t.Errorf("want %v, got %v", want, got)
+			}
+		})
+	}
+
+	var walked []string
+	tb.Walk(func(p netip.Prefix, _ interface{}) bool {
+		walked = append(walked, p.String())
+		return true
+	})
+	if want, got := prefixes, walked; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	tb.Delete(netip.MustParsePrefix("10.1.2.0/24"))
+	tb.Delete(netip.MustParsePrefix("0.0.0.0/0"))
+	if p, _, _ := tb.Lookup(netip.MustParseAddr("10.1.2.4")); p != netip.MustParsePrefix("10.1.0.0/16") {
+		t.Errorf("want 10.1.2.4 to match 10.1.0.0/16, got %v", p)
+	}
+	if p, _, _ := tb.Lookup(netip.MustParseAddr("10.1.2.3")); p != netip.MustParsePrefix("10.1.2.3/32") {
+		t.Errorf("want 10.1.2.3 to match 10.1.2.3/32, got %v", p)
+	}
+	if tb.Contains(netip.MustParseAddr("192.168.2.1")) {
+		t.Errorf("want 192.168.2.1 not to be contained")
+	}
+
+	// IPv4-mapped prefixes are stored as IPv4 ones.
+	tb.Insert(netip.MustParsePrefix("::ffff:172.16.0.0/108"), "mapped")
+	if p, v, _ := tb.Lookup(netip.MustParseAddr("172.16.1.1")); p != netip.MustParsePrefix("172.16.0.0/12") || v != "mapped" {
+		t.Errorf("want 172.16.1.1 to match 172.16.0.0/12, got %v", p)
+	}
+	if !tb.Contains(netip.MustParseAddr("::ffff:172.16.1.1")) {
+		t.Errorf("want ::ffff:172.16.1.1 to be contained")
+	}
+	tb.Delete(netip.MustParsePrefix("::ffff:172.16.0.0/108"))
+	if tb.Contains(netip.MustParseAddr("172.16.1.1")) {
+		t.Errorf("want 172.16.1.1 not to be contained")
+	}
+}
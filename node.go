@@ -1,16 +1,22 @@
 package radix
 
 import (
-	"bytes"
 	"sort"
+	"strings"
 )
 
 // Node is a node of a radix tree.
 type Node struct {
 	Value    interface{}
+	key      string
 	edges    []*edge
+	indices  string // first bytes of edges' labels
+	dups     bool   // whether edges' labels share first bytes
+	idx      *index
 	priority int
 	depth    int
+	count    int   // number of values in the node's subtree, including its own
+	expires  int64 // when the node's value expires, in Unix nanoseconds, if not zero
 }
 
 // Depth returns the node's depth.
@@ -32,82 +38,63 @@ func (n *Node) Priority() int {
 	return n.priority
 }
 
-func (n *Node) addBinary(label string, v interface{}) (nn int) {
-	for i := range label {
-		for j := uint8(8); j > 0; j-- {
-			bbit := bit(j, label[i])
-			done := i == len(label)-1 && j == 1
-			if e := n.edges[bbit]; e != nil {
-				if done {
-					e.n.Value = v
-					return
-				}
-				goto next
-			}
-			n.edges[bbit] = &edge{
-				n: &Node{
-					depth: n.depth + 1,
-					edges: make([]*edge, 2),
-				},
-			}
-			if done {
-				n.edges[bbit].n.Value = v
-			}
-			nn++
-		next:
-			n = n.edges[bbit].n
-		}
+// Key returns the label the node's value was added with.
+func (n *Node) Key() string {
+	return n.key
+}
+
+// addCount adds delta to the count of every node in path.
+func addCount(path []*Node, delta int) {
+	for _, n := range path {
+		n.count += delta
 	}
-	return nn
 }
 
-func (n *Node) clone() *Node {
-	c := *n
-	c.incrDepth()
-	return &c
+func (n *Node) decrDepth() {
+	n.depth--
+	for _, e := range n.edges {
+		e.n.decrDepth()
+	}
 }
 
-func (n *Node) delBinary(label string) int {
-	var (
-		ref *edge
-		del int
-	)
-	for i := range label {
-		for j := uint8(8); j > 0; j-- {
-			bbit := bit(j, label[i])
-			done := i == len(label)-1 && j == 1
-			if e := n.edges[bbit]; e != nil {
-				del++
-				if done && e.n.IsLeaf() { // only delete if node is leaf, otherwise it would break the tree
-					ref.n.edges = make([]*edge, 2) // reset edges from the last node that has value
-					return del
-				}
-				ref = e
-				n = e.n
-				continue
+// get returns the node whose label is exactly label, if any.
+func (n *Node) get(label string) *Node {
+	for label != "" {
+		var next *edge
+		for _, e := range n.lookup(label[0]) {
+			if strings.HasPrefix(label, e.label) {
+				next = e
+				break
 			}
-			return 0
 		}
+		if next == nil {
+			return nil
+		}
+		n = next.n
+		label = label[len(next.label):]
 	}
-	return 0
+	return n
 }
 
-func (n *Node) getBinary(label string) *Node {
-	for i := range label {
-		for j := uint8(8); j > 0; j-- {
-			bbit := bit(j, label[i])
-			done := i == len(label)-1 && j == 1
-			if e := n.edges[bbit]; e != nil {
-				if done {
-					return e.n
-				}
-				n = e.n
-				continue
+func (n *Node) hasPrefix(prefix string, now int64) bool {
+	for prefix != "" {
+		var next *edge
+		for _, e := range n.lookup(prefix[0]) {
+			if strings.HasPrefix(e.label, prefix) {
+				return e.n.holdsAny(now)
 			}
-			return nil
+			if strings.HasPrefix(prefix, e.label) {
+				next = e
+				break
+			}
+		}
+		if next == nil {
+			return false
 		}
+		n = next.n
+		prefix = prefix[len(next.label):]
 	}
-	return nil
+	return n.holdsAny(now)
 }
 
 func (n *Node) incrDepth() {
@@ -117,6 +104,28 @@ func (n *Node) incrDepth() {
 	}
 }
 
+func (n *Node) longestPrefix(label string, now int64) *Node {
+	var match *Node
+	for label != "" {
+		var next *edge
+		for _, e := range n.lookup(label[0]) {
+			if strings.HasPrefix(label, e.label) {
+				next = e
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		n = next.n
+		label = label[len(next.label):]
+		if n.holds(now) {
+			match = n
+		}
+	}
+	return match
+}
+
 // sort sorts the node and its children recursively.
 func (n *Node) sort(st SortingTechnique) {
 	s := &sorter{
@@ -124,46 +133,46 @@ func (n *Node) sort(st SortingTechnique) {
 		st: st,
 	}
 	sort.Sort(s)
+	n.reindex()
 	for _, e := range n.edges {
 		e.n.sort(st)
 	}
 }
 
+func (n *Node) walk(fn WalkFunc) bool {
+	for _, e := range n.edges {
+		if e == nil { // binary trees hold empty edges
+			continue
+		}
+		if e.n.Value != nil && !fn(e.n.key, e.n) {
+			return false
+		}
+		if !e.n.walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
 func (n *Node) writeTo(bd *builder) {
 	for i, e := range n.edges {
-		e.writeTo(bd, []bool{i == len(n.edges)-1})
+		e.writeTo(bd, []bool{i == len(n.edges)-1}, "")
 	}
 }
 
-func (n *Node) writeToBinary(bd *builder, buf, aux *bytes.Buffer) {
-	prefix := aux.Bytes()
-	length := len(prefix)
-	aux1, aux2 := make([]byte, length), make([]byte, length)
-	copy(aux1, prefix)
-	copy(aux2, prefix)
-	auxs := []*bytes.Buffer{
-		bytes.NewBuffer(aux1),
-		bytes.NewBuffer(aux2),
+func (n *Node) writeToBinary(bd *builder, label string) {
+	if n.Value != nil {
+		for i := 0; i < n.depth; i++ {
+			bd.WriteByte('0' + bitAt(label, i))
+		}
+		if n.IsLeaf() {
+			bd.WriteString(bd.colors[colorGreen].Wrap(" 🍂"))
+		}
+		bd.WriteString(bd.colors[colorMagenta].Wrapf(" → %#v\n", n.Value))
 	}
-	for i, e := range n.edges {
+	for _, e := range n.edges {
 		if e != nil {
-			bit := byte('0')
-			if i == 1 {
-				bit = '1'
-			}
-			auxs[i].WriteByte(bit)
-			if e.n != nil {
-				if e.n.Value != nil {
-					bd.Write(prefix)
-					bd.WriteByte(bit) // holds only one value
-					isLeaf := e.n.IsLeaf()
-					if isLeaf {
-						bd.WriteString(bd.colors[colorGreen].Wrap(" 🍂"))
-					}
-					bd.WriteString(bd.colors[colorMagenta].Wrapf(" → %#v\n", e.n.Value))
-				}
-				e.n.writeToBinary(bd, buf, auxs[i])
-			}
+			e.n.writeToBinary(bd, e.label)
 		}
 	}
 }
@@ -0,0 +1,68 @@
+package radix
+
+// Observer is notified of changes made by adding and deleting labels.
+// Its methods are called synchronously while the tree is locked,
+// so they must not call methods of the tree.
+//
+// Edges are reported in the form labels are stored, i.e. transformed
+// by the tree's flags and normalizer. Splits and merges of edges
+// are reported before the change that caused them, except for merges
+// caused by Intersect and Difference, which are reported after the deletions
+// that caused them. They are only reported for prefix trees.
+type Observer interface {
+	// OnAdd is called when v is added to key, replacing old,
+	// which is nil if key didn't hold a value.
+	OnAdd(key string, old, v interface{})
+	// OnDel is called when the value old is deleted from key.
+	OnDel(key string, old interface{})
+	// OnSplit is called when an edge is split into an edge
+	// labeled prefix and another one labeled suffix.
+	OnSplit(prefix, suffix string)
+	// OnMerge is called when an edge labeled prefix and its only
+	// edge, labeled suffix, are merged into a single edge.
+	OnMerge(prefix, suffix string)
+}
+
+type observer struct {
+	Observer
+}
+
+// Observe registers o, which is notified of changes made by Add, Del and their variants,
+// as well as by Merge, Intersect and Difference, and returns a function that unregisters it.
+func (tr *Tree) Observe(o Observer) func() {
+	obs := &observer{o}
+	if tr.safe {
+		tr.mu.Lock()
+	}
+	tr.observers = append(tr.observers, obs)
+	if tr.safe {
+		tr.mu.Unlock()
+	}
+	return func() {
+		if tr.safe {
+			defer tr.mu.Unlock()
+			tr.mu.Lock()
+		}
+		for i := range tr.observers {
+			if tr.observers[i] == obs {
+				last := len(tr.observers) - 1
+				copy(tr.observers[i:], tr.observers[i+1:])
+				tr.observers[last] = nil
+				tr.observers = tr.observers[:last]
+				return
+			}
+		}
+	}
+}
+
+func (tr *Tree) notifySplit(prefix, suffix string) {
+	for _, o := range tr.observers {
+		o.OnSplit(prefix, suffix)
+	}
+}
+
+func (tr *Tree) notifyMerge(prefix, suffix string) {
+	for _, o := range tr.observers {
+		o.OnMerge(prefix, suffix)
+	}
+}
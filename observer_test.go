@@ -0,0 +1,137 @@
+package radix_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+type recorder []string
+
+func (r *recorder) OnAdd(key string, old, v interface{}) {
+	*r = append(*r, fmt.Sprintf("add %s %v %v", key, old, v))
+}
+
+func (r *recorder) OnDel(key string, old interface{}) {
+	*r = append(*r, fmt.Sprintf("del %s %v", key, old))
+}
+
+func (r *recorder) OnSplit(prefix, suffix string) {
+	*r = append(*r, fmt.Sprintf("split %s %s", prefix, suffix))
+}
+
+func (r *recorder) OnMerge(prefix, suffix string) {
+	*r = append(*r, fmt.Sprintf("merge %s %s", prefix, suffix))
+}
+
+func TestObserve(t *testing.T) {
+	testCases := []struct {
+		flags int
+		want  []string
+	}{
+		{0, []string{
+			"add tomato <nil> 1",
+			"split tom ato",
+			"add tom <nil> 2",
+			"split to m",
+			"add tornado <nil> 3",
+			"add tom 2 4",
+			"merge m ato",
+			"del tom 4",
+			"merge to mato",
+			"del tornado 3",
+		}},
+		{Tsafe | Tfold, []string{
+			"add tomato <nil> 1",
+			"split tom ato",
+			"add tom <nil> 2",
+			"split to m",
+			"add tornado <nil> 3",
+			"add tom 2 4",
+			"merge m ato",
+			"del tom 4",
+			"merge to mato",
+			"del tornado 3",
+		}},
+		{Tbinary, []string{
+			"add tomato <nil> 1",
+			"add tom <nil> 2",
+			"add tornado <nil> 3",
+			"add tom 2 4",
+			"del tom 4",
+			"del tornado 3",
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(tc.flags)
+			var r recorder
+			cancel := tr.Observe(&r)
+			tr.Add("tomato", 1)
+			tr.Add("tom", 2)
+			tr.Add("tornado", 3)
+			tr.Add("tom", 4)
+			tr.Del("tom")
+			tr.Del("toma") // not in the tree
+			tr.Del("tornado")
+			if want, got := tc.want, []string(r); !reflect.DeepEqual(want, got) {
+				t.Errorf("want %q, got %q", want, got)
+			}
+
+			cancel()
+			tr.Add("tom", 5)
+			if want, got := len(tc.want), len(r); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+		})
+	}
+
+	// Set operations are also reported.
+	for _, flags := range []int{0, Tfold} {
+		tr := New(0)
+		tr.Add("tomato", 1)
+		tr.Add("tornado", 2)
+		var r recorder
+		tr.Observe(&r)
+		other := New(flags)
+		other.Add("tom", 3)
+		other.Add("tomatoes", 4)
+		tr.Merge(other, nil)
+		tr.Intersect(other, func(key string, a, b interface{}) interface{} { return 5 })
+		tr.Difference(other)
+		want := []string{
+			"split m ato",
+			"add tom <nil> 3",
+			"add tomatoes <nil> 4",
+			"add tom 3 5",
+			"del tomato 1",
+			"add tomatoes 4 5",
+			"merge ato es",
+			"del tornado 2",
+			"merge to m",
+			"del tom 5",
+			"del tomatoes 5",
+		}
+		if flags != 0 { // labels are added and deleted one by one
+			want = []string{
+				"split m ato",
+				"add tom <nil> 3",
+				"add tomatoes <nil> 4",
+				"add tom 3 5",
+				"add tomatoes 4 5",
+				"merge ato es",
+				"del tomato 1",
+				"merge to m",
+				"del tornado 2",
+				"merge tom atoes",
+				"del tom 5",
+				"del tomatoes 5",
+			}
+		}
+		if want, got := want, []string(r); !reflect.DeepEqual(want, got) {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	}
+}
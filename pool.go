@@ -0,0 +1,38 @@
+package radix
+
+// poolChunk is the number of edges allocated at once by pools.
+const poolChunk = 128
+
+// pair holds an edge together with the node it leads to,
+// so both are allocated at once.
+type pair struct {
+	e edge
+	n Node
+}
+
+// pool batches allocations of edges and their nodes in chunks.
+// A nil pool allocates each pair by itself.
+//
+// Pairs hold pointers, so chunks are still scanned by the garbage collector.
+// They are also never reused, since callers may hold their nodes, so a chunk
+// is only collected once none of its edges and nodes is referenced anymore.
+type pool struct {
+	chunk []pair
+}
+
+// newEdge creates an edge that leads to a copy of n.
+func (pl *pool) newEdge(label string, n Node) *edge {
+	var p *pair
+	if pl == nil {
+		p = &pair{}
+	} else {
+		if len(pl.chunk) == cap(pl.chunk) {
+			pl.chunk = make([]pair, 0, poolChunk)
+		}
+		pl.chunk = pl.chunk[:len(pl.chunk)+1]
+		p = &pl.chunk[len(pl.chunk)-1]
+	}
+	p.n = n
+	p.e = edge{label: label, n: &p.n}
+	return &p.e
+}
@@ -0,0 +1,50 @@
+package radix_test
+
+import (
+	"strconv"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestPool(t *testing.T) {
+	testCases := []struct {
+		flags  int
+		labels []string
+	}{
+		{0, nil},
+		{Tbinary, nil},
+	}
+	for i := 0; i < 1000; i++ { // more than a chunk
+		label := strconv.Itoa(i * 7919)
+		testCases[0].labels = append(testCases[0].labels, label)
+		testCases[1].labels = append(testCases[1].labels, label)
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr, ptr := New(tc.flags|Tnocolor), New(tc.flags|Tnocolor|Tpool)
+			for i, label := range tc.labels {
+				tr.Add(label, i)
+				ptr.Add(label, i)
+			}
+			for _, label := range tc.labels[:len(tc.labels)/2] {
+				tr.Del(label)
+				ptr.Del(label)
+			}
+			if want, got := tr.Len(), ptr.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			if want, got := tr.Size(), ptr.Size(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			if want, got := tr.String(), ptr.String(); want != got {
+				t.Errorf("want %q, got %q", want, got)
+			}
+			for i, label := range tc.labels[len(tc.labels)/2:] {
+				if n, _ := ptr.Get(label); n == nil || n.Value != len(tc.labels)/2+i {
+					t.Fatalf("want %q to be found", label)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package radix
+
+import (
+	"sort"
+	"strings"
+)
+
+// Count returns the number of values in the tree.
+//
+// Expired values are skipped, so, for trees with values added with TTLs,
+// Count, Rank and Select walk the tree instead of using counts of subtrees.
+func (tr *Tree) Count() int {
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	return tr.root.countAt(tr.now())
+}
+
+// Rank returns the number of labels in the tree that are lexicographically
+// less than label, comparing labels as they're stored. That is, labels of
+// case-insensitive trees are compared folded and labels of reversed trees,
+// reversed. Labels of binary trees are compared bit by bit.
+//
+// Label doesn't need to be in the tree, which makes Rank useful for
+// finding where labels would be in the order of the tree.
+func (tr *Tree) Rank(label string) int {
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	label = tr.key(label)
+	if tr.binary {
+		return tr.root.rankBinary(label, len(label)*8, tr.now())
+	}
+	return tr.root.rank(label, tr.now())
+}
+
+// Select returns the key and value of the label whose rank is i.
+// If i is out of range, it returns an empty key and a nil value.
+func (tr *Tree) Select(i int) (string, interface{}) {
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	now := tr.now()
+	if i < 0 || i >= tr.root.countAt(now) {
+		return "", nil
+	}
+	n := tr.root.selectAt(i, now)
+	return n.key, n.Value
+}
+
+func (n *Node) rank(label string, now int64) int {
+	r := 0
+	for label != "" {
+		if n.holds(now) { // a prefix of label
+			r++
+		}
+		var next *edge
+		for _, e := range n.edges {
+			if strings.HasPrefix(label, e.label) {
+				next = e
+				continue
+			}
+			if e.label < label {
+				r += e.n.countAt(now)
+			}
+		}
+		if next == nil {
+			break
+		}
+		n = next.n
+		label = label[len(next.label):]
+	}
+	return r
+}
+
+func (n *Node) rankBinary(label string, nbits int, now int64) int {
+	r := 0
+	for n.depth < nbits {
+		if n.holds(now) {
+			r++
+		}
+		bbit := bitAt(label, n.depth)
+		if bbit == 1 && n.edges[0] != nil {
+			r += n.edges[0].n.countAt(now)
+		}
+		e := n.edges[bbit]
+		if e == nil {
+			break
+		}
+		end := e.n.depth
+		if nbits < end {
+			end = nbits
+		}
+		if i := commonBits(e.label, label, n.depth+1, end); i < end {
+			if bitAt(label, i) == 1 {
+				r += e.n.countAt(now)
+			}
+			break
+		}
+		if end < e.n.depth { // label ends in the middle of the edge
+			break
+		}
+		n = e.n
+	}
+	return r
+}
+
+// selectAt returns the node in the i-th position of its subtree, skipping values expired at now.
+func (n *Node) selectAt(i int, now int64) *Node {
+	var buf [16]*edge
+	for {
+		if n.holds(now) {
+			if i == 0 {
+				return n
+			}
+			i--
+		}
+		var next *edge
+		for _, e := range n.sortedEdges(buf[:0]) {
+			c := e.n.countAt(now)
+			if i < c {
+				next = e
+				break
+			}
+			i -= c
+		}
+		if next == nil {
+			return nil
+		}
+		n = next.n
+	}
+}
+
+// sortedEdges appends the node's edges to buf in ascending order of labels.
+func (n *Node) sortedEdges(buf []*edge) []*edge {
+	for _, e := range n.edges {
+		if e != nil { // binary trees hold empty edges
+			buf = append(buf, e)
+		}
+	}
+	less := func(i, j int) bool { return buf[i].label < buf[j].label }
+	if !sort.SliceIsSorted(buf, less) {
+		sort.Slice(buf, less)
+	}
+	return buf
+}
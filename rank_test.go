@@ -0,0 +1,78 @@
+package radix_test
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestRank(t *testing.T) {
+	words := []string{
+		"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus",
+		"test", "toaster", "toasting", "slow", "slowly", "tom", "tomato", "tornado",
+		"a", "ab", "abc", "b", "áb", "ác", "日本", "日本語", "日月",
+	}
+	for i := 0; i < 300; i++ {
+		words = append(words, strconv.Itoa(i*7919))
+	}
+	missing := []string{"", "r", "roman", "romanes", "日", "zzz", "tomatoes", "0", "1", "\xff"}
+	testCases := []struct {
+		flags int
+		build bool
+	}{
+		{0, false},
+		{Trune, false},
+		{Tfold, false},
+		{Tbinary, false},
+		{0, true},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var tr *Tree
+			if tc.build {
+				sorted := append([]string(nil), words...)
+				sort.Strings(sorted)
+				values := make([]interface{}, len(sorted))
+				for i, w := range sorted {
+					values[i] = w
+				}
+				tr, _ = FromSortedSlice(tc.flags, sorted, values)
+			} else {
+				tr = New(tc.flags)
+				for _, w := range words {
+					tr.Add(w, 0)
+					tr.Add(w, w) // replacing values doesn't change counts
+				}
+				tr.Add("deleted", 1)
+				tr.Add("deleted/child", 2)
+				tr.Del("deleted")
+				tr.Del("deleted/child")
+				tr.Del("missing")
+			}
+			tr.Sort(PrioritySort)
+
+			sorted := append([]string(nil), words...)
+			sort.Strings(sorted)
+			if want, got := len(words), tr.Count(); want != got {
+				t.Fatalf("want %d, got %d", want, got)
+			}
+			for _, label := range append(missing, words...) {
+				if want, got := sort.SearchStrings(sorted, label), tr.Rank(label); want != got {
+					t.Errorf("want rank %d for %q, got %d", want, label, got)
+				}
+			}
+			for i, w := range sorted {
+				if key, v := tr.Select(i); key != w || v != w {
+					t.Errorf("want %q at %d, got %q", w, i, key)
+				}
+			}
+			for _, i := range []int{-1, len(words)} {
+				if key, v := tr.Select(i); key != "" || v != nil {
+					t.Errorf("want nothing at %d, got %q", i, key)
+				}
+			}
+		})
+	}
+}
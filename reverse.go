@@ -0,0 +1,15 @@
+package radix
+
+import "unicode/utf8"
+
+// reverse reverses s rune by rune.
+// Invalid UTF-8 bytes are treated as single runes.
+func reverse(s string) string {
+	b := make([]byte, len(s))
+	for i := 0; i < len(s); {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		copy(b[len(s)-i-size:], s[i:i+size])
+		i += size
+	}
+	return string(b)
+}
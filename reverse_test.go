@@ -0,0 +1,99 @@
+package radix_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestReverse(t *testing.T) {
+	tr := New(Tdebug | Treverse)
+	labels := []string{"example.com", "api.example.com", "example.org", "東京.jp"}
+	for i, label := range labels {
+		tr.Add(label, i)
+	}
+	t.Log(tr.String())
+
+	for i, label := range labels {
+		n, _ := tr.Get(label)
+		if n == nil {
+			t.Fatalf("want %q to be found", label)
+		}
+		if want, got := i, n.Value; want != got {
+			t.Errorf("want %v, got %v", want, got)
+		}
+	}
+	longestCases := []struct {
+		label string
+		key   string
+	}{
+		{label: "www.api.example.com", key: "api.example.com"},
+		{label: "foo.example.com", key: "example.com"},
+		{label: "example.com", key: "example.com"},
+		{label: "京都.東京.jp", key: "東京.jp"},
+		{label: "xample.com"},
+		{label: "example.net"},
+	}
+	for _, tc := range longestCases {
+		var key string
+		if n := tr.LongestSuffix(tc.label); n != nil {
+			key = n.Key()
+		}
+		if want, got := tc.key, key; want != got {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	}
+	suffixCases := []struct {
+		suffix string
+		found  bool
+	}{
+		{suffix: ".com", found: true},
+		{suffix: "xample.org", found: true},
+		{suffix: "i.example.com", found: true},
+		{suffix: "京.jp", found: true},
+		{suffix: ".net", found: false},
+		{suffix: "www.example.com", found: false},
+	}
+	for _, tc := range suffixCases {
+		if want, got := tc.found, tr.HasSuffix(tc.suffix); want != got {
+			t.Errorf("want %t for %q, got %t", want, tc.suffix, got)
+		}
+	}
+	var keys []string
+	tr.Walk(func(key string, _ *Node) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if want, got := labels, keys; !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if tr.LongestPrefix("example.com") != nil || tr.HasPrefix("example") {
+		t.Errorf("want prefix lookups to be disabled for reversed trees")
+	}
+
+	// Labels are printed in their original order.
+	tr = New(Treverse)
+	tr.Add("example.com", 1)
+	tr.Add("api.example.com", 2)
+	s := tr.String()
+	for _, label := range []string{"example.com", "api.example.com"} {
+		if !strings.Contains(s, label) {
+			t.Errorf("want %q to be printed, got %q", label, s)
+		}
+	}
+	if strings.Contains(s, "moc") || strings.Contains(s, "ipa") {
+		t.Errorf("want no reversed labels to be printed, got %q", s)
+	}
+
+	// Named labels aren't supported.
+	tr.SetBoundaries('@', '/')
+	tr.Add("/users/@id", 3)
+	if n, _ := tr.Get("/users/42"); n != nil {
+		t.Errorf("want %q not to match %q", "/users/42", "/users/@id")
+	}
+	if n, _ := tr.Get("/users/@id"); n == nil || n.Value != 3 {
+		t.Errorf("want %d, got %v", 3, n)
+	}
+}
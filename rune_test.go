@@ -0,0 +1,80 @@
+package radix_test
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestRune(t *testing.T) {
+	testCases := []struct {
+		labels []string
+		flags  int
+		length int
+	}{
+		{
+			labels: []string{"日本", "日曜", "日本語"},
+			flags:  Trune,
+			length: 5,
+		},
+		{
+			labels: []string{"日本", "日曜", "日本語"},
+			length: 5,
+		},
+		{
+			labels: []string{"🙂", "🙃", "🙂🙃"},
+			flags:  Trune,
+			length: 4,
+		},
+		{
+			labels: []string{"🙂", "🙃", "🙂🙃"},
+			length: 5,
+		},
+		{
+			labels: []string{"café", "cafè", "caffè", "東京", "京都"},
+			flags:  Trune,
+			length: 7,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(Tdebug | Tnocolor | tc.flags)
+			for i, label := range tc.labels {
+				tr.Add(label, i)
+			}
+			s := tr.String()
+			t.Log(s)
+
+			if want, got := tc.length, tr.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			if want, got := tc.flags&Trune > 0, utf8.ValidString(s); want && !got {
+				t.Errorf("want %q to be valid UTF-8", s)
+			}
+			for i, label := range tc.labels {
+				n, _ := tr.Get(label)
+				if n == nil {
+					t.Fatalf("want %q to be found", label)
+				}
+				if want, got := i, n.Value; want != got {
+					t.Errorf("want %v, got %v", want, got)
+				}
+			}
+			for i, label := range tc.labels {
+				tr.Del(label)
+				if n, _ := tr.Get(label); n != nil {
+					t.Errorf("want %q to be deleted", label)
+				}
+				for _, label := range tc.labels[i+1:] {
+					if n, _ := tr.Get(label); n == nil {
+						t.Errorf("want %q to be kept", label)
+					}
+				}
+			}
+			if want, got := 1, tr.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+		})
+	}
+}
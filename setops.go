@@ -0,0 +1,701 @@
+package radix
+
+import (
+	"strings"
+	"unsafe"
+)
+
+// ResolveFunc is called with the key of a label held by two trees and
+// both of its values, returning the value to be kept. If it returns nil,
+// the value of the tree being changed is kept.
+type ResolveFunc func(key string, a, b interface{}) interface{}
+
+// Merge adds the labels of other to the tree, which then holds the union of both trees.
+// When both trees hold a label, resolve decides its value. If resolve is nil,
+// other's value is kept.
+//
+// Trees with the same flags are merged by walking both of them at once,
+// so subtrees held only by other are copied instead of having their labels added one by one.
+// Either way, watchers and observers are notified of every added and updated label,
+// and bounded trees evict labels until they're within their capacity again.
+//
+// Expired values of other are skipped, and expired values of the tree
+// are replaced as if the tree didn't hold them.
+func (tr *Tree) Merge(other *Tree, resolve ResolveFunc) {
+	defer tr.lockWith(other)()
+	if other == tr {
+		tr.resolveAll(resolve)
+		return
+	}
+	tr.expiring = tr.expiring || other.expiring
+	now := tr.now()
+	if !tr.compatible(other) {
+		other.walkLabels(other.root, "", func(label string, nbits int, n *Node) {
+			if n.expired(now) {
+				return
+			}
+			label, nbits = tr.convert(other, label, nbits, n.key)
+			if label == "" && !tr.binary {
+				return
+			}
+			v, exp := n.Value, n.expires
+			if old := tr.find(label, nbits); old != nil && !old.expired(now) {
+				if resolve != nil {
+					exp = old.expires
+				}
+				v = resolveValue(resolve, n.key, old.Value, v)
+			}
+			tr.set(label, nbits, n.key, v, exp)
+		})
+		return
+	}
+	other = other.unexpired(now)
+	if tr.binary {
+		tr.root.count += tr.mergeBinary(tr.root, other.root, resolve, now, "")
+	} else {
+		tr.root.count += tr.merge(tr.root, other.root, resolve, now, nil)
+	}
+	if tr.bound != nil {
+		tr.shrink()
+	}
+}
+
+// Intersect removes the labels of the tree that are not held by other.
+// The values of remaining labels are decided by resolve. If resolve is nil,
+// the tree's values are kept. Watchers and observers are notified of
+// every deleted label and, if resolve isn't nil, of every updated one.
+// Labels whose values expired in other aren't held by it.
+func (tr *Tree) Intersect(other *Tree, resolve ResolveFunc) {
+	defer tr.lockWith(other)()
+	if other == tr {
+		tr.resolveAll(resolve)
+		return
+	}
+	tr.filter(other, true, resolve)
+}
+
+// Difference removes the labels of the tree that are held by other.
+// Watchers and observers are notified of every deleted label.
+// Labels whose values expired in other aren't held by it.
+func (tr *Tree) Difference(other *Tree) {
+	defer tr.lockWith(other)()
+	if other == tr {
+		tr.walkLabels(tr.root, "", func(label string, nbits int, n *Node) {
+			tr.pruned(target{label, nbits}, n.key, n.Value)
+		})
+		tr.root = &Node{}
+		if tr.binary {
+			tr.root.edges = make([]*edge, 2)
+		}
+		tr.length, tr.size, tr.bits = 1, 0, 0
+		return
+	}
+	tr.filter(other, false, nil)
+}
+
+// lockWith locks the tree for writing and other for reading, always in the same
+// order, so trees being merged into each other concurrently don't deadlock.
+func (tr *Tree) lockWith(other *Tree) func() {
+	switch {
+	case other == tr || !other.safe:
+		if tr.safe {
+			tr.mu.Lock()
+			return tr.mu.Unlock
+		}
+		return func() {}
+	case !tr.safe:
+		other.mu.RLock()
+		return other.mu.RUnlock
+	}
+	if uintptr(unsafe.Pointer(tr)) < uintptr(unsafe.Pointer(other)) {
+		tr.mu.Lock()
+		other.mu.RLock()
+	} else {
+		other.mu.RLock()
+		tr.mu.Lock()
+	}
+	return func() {
+		tr.mu.Unlock()
+		other.mu.RUnlock()
+	}
+}
+
+// compatible returns whether labels of both trees are stored the same way.
+func (tr *Tree) compatible(other *Tree) bool {
+	return tr.binary == other.binary &&
+		tr.fold == other.fold &&
+		tr.runes == other.runes &&
+		tr.reverse == other.reverse &&
+		tr.host == other.host &&
+		(!tr.host || tr.delim == other.delim) &&
+		tr.normalize == nil && other.normalize == nil
+}
+
+// convert returns how a label of other, which is stored as the first nbits bits
+// of label and was added as key, is stored in the tree, as well as its number of bits.
+func (tr *Tree) convert(other *Tree, label string, nbits int, key string) (string, int) {
+	if tr.binary && other.binary {
+		return label, nbits
+	}
+	label = tr.key(key)
+	return label, len(label) * 8
+}
+
+// find returns the node whose stored label is the first nbits bits of label, if it holds a value.
+func (tr *Tree) find(label string, nbits int) *Node {
+	var n *Node
+	if tr.binary {
+		n = tr.root.getBinary(label, nbits)
+	} else {
+		n = tr.root.get(label)
+	}
+	if n == nil || n.Value == nil {
+		return nil
+	}
+	return n
+}
+
+func (tr *Tree) resolveAll(resolve ResolveFunc) {
+	if resolve == nil {
+		return
+	}
+	tr.walkLabels(tr.root, "", func(label string, nbits int, n *Node) {
+		old := n.Value
+		n.Value = resolveValue(resolve, n.key, old, old)
+		tr.merged(target{label, nbits}, n.key, old, n.Value)
+	})
+}
+
+func resolveValue(resolve ResolveFunc, key string, a, b interface{}) interface{} {
+	if resolve == nil {
+		return b
+	}
+	if v := resolve(key, a, b); v != nil {
+		return v
+	}
+	return a
+}
+
+// walkAll calls fn for every node in n's subtree that holds a value.
+func (n *Node) walkAll(fn func(n *Node)) {
+	if n.Value != nil {
+		fn(n)
+	}
+	for _, e := range n.edges {
+		if e != nil {
+			e.n.walkAll(fn)
+		}
+	}
+}
+
+// walkLabels calls fn for every node in n's subtree that holds a value, passing the node's
+// stored label and its number of bits. Label is n's stored label or, for binary trees,
+// the label of the edge that leads to n.
+func (tr *Tree) walkLabels(n *Node, label string, fn func(label string, nbits int, n *Node)) {
+	if n.Value != nil {
+		if tr.binary {
+			t := tr.target(label, n.depth)
+			fn(t.label, t.nbits, n)
+		} else {
+			fn(label, len(label)*8, n)
+		}
+	}
+	for _, e := range n.edges {
+		switch {
+		case e == nil:
+		case tr.binary:
+			tr.walkLabels(e.n, e.label, fn)
+		default:
+			tr.walkLabels(e.n, label+e.label, fn)
+		}
+	}
+}
+
+// reporting returns whether changes to the tree are reported to anyone,
+// including its bound, which tracks the labels it may evict.
+func (tr *Tree) reporting() bool {
+	return len(tr.watchers) > 0 || len(tr.observers) > 0 || tr.bound != nil
+}
+
+// merged reports that v was set to the node of t, replacing old, just like set does.
+func (tr *Tree) merged(t target, key string, old, v interface{}) {
+	if tr.bound != nil {
+		tr.bound.touch(t)
+	}
+	tr.notifyAdd(t.label, key, old, v)
+}
+
+// pruned reports that old was deleted from the node of t, just like unset does.
+func (tr *Tree) pruned(t target, key string, old interface{}) {
+	if tr.bound != nil {
+		tr.bound.untrack(t)
+	}
+	tr.notifyDel(t.label, key, old)
+}
+
+// labelTarget returns the target of n, whose label is label,
+// which is the label of the edge that leads to n for binary trees.
+func (tr *Tree) labelTarget(label string, n *Node) target {
+	if tr.binary {
+		return tr.target(label, n.depth)
+	}
+	return target{label, len(label) * 8}
+}
+
+// reportAdded reports every value of the subtree that was added at label,
+// which is the label of the edge that leads to n for binary trees.
+func (tr *Tree) reportAdded(n *Node, label string) {
+	tr.walkLabels(n, label, func(label string, nbits int, n *Node) {
+		tr.merged(target{label, nbits}, n.key, nil, n.Value)
+	})
+}
+
+// reportDeleted reports every value of the subtree at label as deleted.
+func (tr *Tree) reportDeleted(n *Node, label string) {
+	tr.walkLabels(n, label, func(label string, nbits int, n *Node) {
+		tr.pruned(target{label, nbits}, n.key, n.Value)
+	})
+}
+
+// mergeValue merges the value of b into a, returning 1 if a had no value.
+// If a's value expired at now, it's replaced by b's as if a had none.
+func mergeValue(a, b *Node, resolve ResolveFunc, now int64) int {
+	switch {
+	case b.Value == nil:
+		return 0
+	case a.holds(now):
+		resolveNode(resolve, a, b)
+		return 0
+	}
+	var added int
+	if a.Value == nil {
+		added = 1
+	}
+	a.Value = b.Value
+	a.key = b.key
+	a.expires = b.expires
+	return added
+}
+
+// unexpired returns the tree or, if any of its values expired at now,
+// a copy of it without them, whose subtrees can be merged as they are.
+func (tr *Tree) unexpired(now int64) *Tree {
+	if tr.root.countAt(now) == tr.root.count {
+		return tr
+	}
+	c := tr.clone()
+	c.expire("", func(n *Node) bool { return n.expired(now) })
+	return c
+}
+
+// resolveNode sets a's value to the result of resolving the values of a and b.
+// When b's value wins, so does its expiry.
+func resolveNode(resolve ResolveFunc, a, b *Node) {
+	if resolve == nil {
+		a.expires = b.expires
+	}
+	a.Value = resolveValue(resolve, a.key, a.Value, b.Value)
+}
+
+// merge merges b's subtree into a's, where both nodes are reached by the same label,
+// which is path, and values expire at now. It returns how many values were added.
+func (tr *Tree) merge(a, b *Node, resolve ResolveFunc, now int64, path []byte) int {
+	a.priority += b.priority
+	old := a.Value
+	if a.expired(now) {
+		old = nil
+	}
+	added := mergeValue(a, b, resolve, now)
+	if b.Value != nil && tr.reporting() {
+		tr.merged(tr.labelTarget(string(path), a), a.key, old, a.Value)
+	}
+	for _, e := range b.edges {
+		added += tr.mergeEdge(a, e.label, e.n, resolve, now, path)
+	}
+	return added
+}
+
+// mergeEdge merges an edge of other, whose label is label and leads to bn, into a, which is reached by path.
+// It returns how many values were added to a's subtree, excluding a.
+func (tr *Tree) mergeEdge(a *Node, label string, bn *Node, resolve ResolveFunc, now int64, path []byte) int {
+	var (
+		ea *edge
+		i  int
+	)
+	for _, e := range a.lookup(label[0]) {
+		i = 0
+		for i < len(e.label) && i < len(label) && e.label[i] == label[i] {
+			i++
+		}
+		if i = tr.cut(e.label, label, i); i > 0 {
+			ea = e
+			break
+		}
+	}
+	if ea == nil {
+		c := tr.cloneEdge(label, bn, a.depth)
+		a.addEdge(c)
+		if tr.reporting() {
+			tr.reportAdded(c.n, string(path)+label)
+		}
+		return bn.count
+	}
+	if i < len(ea.label) {
+		// Split the edge just like when adding a label that diverges from it.
+		tr.notifySplit(ea.label[:i], ea.label[i:])
+		c := tr.pool.newEdge(ea.label[i:], *ea.n)
+		c.n.incrDepth()
+		ea.n.edges = []*edge{c}
+		ea.n.reindex()
+		ea.n.Value = nil
+		ea.n.key = ""
+		ea.n.expires = 0
+		ea.label = ea.label[:i]
+		tr.length++
+	}
+	path = append(path, ea.label...)
+	var added int
+	if i == len(label) {
+		added = tr.merge(ea.n, bn, resolve, now, path)
+	} else {
+		// bn is below ea's node.
+		ea.n.priority += bn.priority
+		added = tr.mergeEdge(ea.n, label[i:], bn, resolve, now, path)
+	}
+	ea.n.count += added
+	return added
+}
+
+// mergeBinary is like merge, but for binary trees,
+// where label is the label of the edge that leads to a.
+func (tr *Tree) mergeBinary(a, b *Node, resolve ResolveFunc, now int64, label string) int {
+	old := a.Value
+	if a.expired(now) {
+		old = nil
+	}
+	added := mergeValue(a, b, resolve, now)
+	if b.Value != nil && tr.reporting() {
+		tr.merged(tr.target(label, a.depth), a.key, old, a.Value)
+	}
+	for bit, e := range b.edges {
+		if e != nil {
+			added += tr.mergeEdgeBinary(a, bit, e.label, e.n, resolve, now)
+		}
+	}
+	return added
+}
+
+func (tr *Tree) mergeEdgeBinary(a *Node, bit int, label string, bn *Node, resolve ResolveFunc, now int64) int {
+	ea := a.edges[bit]
+	if ea == nil {
+		a.edges[bit] = tr.cloneEdge(label, bn, a.depth)
+		if tr.reporting() {
+			tr.reportAdded(a.edges[bit].n, label)
+		}
+		return bn.count
+	}
+	end := ea.n.depth
+	if bn.depth < end {
+		end = bn.depth
+	}
+	i := commonBits(ea.label, label, a.depth+1, end)
+	if i < ea.n.depth {
+		// Split the edge where the labels diverge or where other's label ends.
+		c := tr.pool.newEdge(ea.label, Node{
+			depth: i,
+			edges: make([]*edge, 2),
+			count: ea.n.count,
+		})
+		c.n.edges[bitAt(ea.label, i)] = ea
+		a.edges[bit] = c
+		ea = c
+		tr.length++
+	}
+	var added int
+	if i == bn.depth {
+		added = tr.mergeBinary(ea.n, bn, resolve, now, ea.label)
+	} else {
+		added = tr.mergeEdgeBinary(ea.n, int(bitAt(label, i)), label, bn, resolve, now)
+	}
+	ea.n.count += added
+	return added
+}
+
+// cloneEdge returns a deep copy of an edge whose label is label and leads to n,
+// which is added below a node of depth depth.
+func (tr *Tree) cloneEdge(label string, n *Node, depth int) *edge {
+	c := tr.pool.newEdge(label, *n)
+	tr.length++
+	if tr.binary {
+		tr.bits += n.depth - depth
+	} else {
+		c.n.depth = depth + 1
+		tr.size += len(label)
+	}
+	c.n.edges = make([]*edge, len(n.edges))
+	for i, e := range n.edges {
+		if e != nil {
+			c.n.edges[i] = tr.cloneEdge(e.label, e.n, c.n.depth)
+		}
+	}
+	if n.idx != nil {
+		c.n.reindex() // the index points to n's edges
+	}
+	return c
+}
+
+// filter removes labels of the tree depending on whether other holds them.
+func (tr *Tree) filter(other *Tree, intersect bool, resolve ResolveFunc) {
+	now := other.now()
+	if !tr.compatible(other) {
+		var del []target
+		tr.walkLabels(tr.root, "", func(label string, nbits int, n *Node) {
+			o := other.find(other.convert(tr, label, nbits, n.key))
+			if o != nil && o.expired(now) {
+				o = nil
+			}
+			switch {
+			case (o != nil) != intersect:
+				del = append(del, target{label, nbits})
+			case intersect && resolve != nil:
+				old := n.Value
+				n.Value = resolveValue(resolve, n.key, old, o.Value)
+				tr.merged(target{label, nbits}, n.key, old, n.Value)
+			}
+		})
+		for _, t := range del {
+			tr.unset(t.label, t.nbits)
+		}
+		return
+	}
+	f := filter{tr: tr, intersect: intersect, resolve: resolve, reporting: tr.reporting(), now: now}
+	if tr.binary {
+		tr.root.count -= f.pruneBinary(tr.root, binCursor{n: other.root}, true, "")
+		return
+	}
+	tr.root.count -= f.prune(tr.root, cursor{n: other.root}, true, nil)
+}
+
+type filter struct {
+	tr        *Tree
+	intersect bool
+	resolve   ResolveFunc
+	reporting bool  // whether labels of nodes are needed to report changes
+	now       int64 // when values of other are checked for expiry
+}
+
+// value removes or resolves n's value, returning 1 if it's removed. Its label is label,
+// which is the label of the edge that leads to n for binary trees.
+// When other holds the same label, o is its node.
+func (f *filter) value(n, o *Node, label string) int {
+	if n.Value == nil {
+		return 0
+	}
+	if (o != nil) != f.intersect {
+		key, old := n.key, n.Value
+		n.Value = nil
+		n.key = ""
+		n.expires = 0
+		f.tr.pruned(f.tr.labelTarget(label, n), key, old)
+		return 1
+	}
+	if f.intersect && f.resolve != nil {
+		old := n.Value
+		n.Value = resolveValue(f.resolve, n.key, old, o.Value)
+		f.tr.merged(f.tr.labelTarget(label, n), n.key, old, n.Value)
+	}
+	return 0
+}
+
+// prune removes values from n's subtree, where c is the position
+// of n's label, which is path, in other. If ok is false, other holds no labels
+// that start with n's label. It returns how many values were removed.
+func (f *filter) prune(n *Node, c cursor, ok bool, path []byte) int {
+	var o *Node
+	if ok && c.rest == "" && c.n.holds(f.now) {
+		o = c.n
+	}
+	var label string
+	if f.reporting && n.Value != nil {
+		label = string(path)
+	}
+	removed := f.value(n, o, label)
+	if !ok && !f.intersect {
+		return removed
+	}
+	tr := f.tr
+	edges := n.edges
+	n.edges = n.edges[:0]
+	for _, e := range edges {
+		if !ok {
+			if f.reporting {
+				tr.reportDeleted(e.n, string(path)+e.label)
+			}
+			removed += e.n.count
+			nodes, size := e.n.stats(false)
+			tr.length -= nodes + 1
+			tr.size -= size + len(e.label)
+			continue
+		}
+		cc, cok := c.next(e.label)
+		r := f.prune(e.n, cc, cok, append(path, e.label...))
+		e.n.count -= r
+		e.n.priority -= r
+		removed += r
+		if e.n.Value == nil {
+			switch len(e.n.edges) {
+			case 0:
+				tr.length--
+				tr.size -= len(e.label)
+				continue
+			case 1:
+				tr.notifyMerge(e.label, e.n.edges[0].label)
+				e.merge()
+				tr.length--
+			}
+		}
+		n.edges = append(n.edges, e)
+	}
+	for i := len(n.edges); i < len(edges); i++ {
+		edges[i] = nil
+	}
+	if len(n.edges) < len(edges) {
+		n.reindex()
+	}
+	return removed
+}
+
+// pruneBinary is like prune, but for binary trees,
+// where label is the label of the edge that leads to n.
+func (f *filter) pruneBinary(n *Node, c binCursor, ok bool, label string) int {
+	var o *Node
+	if ok && c.n.depth == n.depth && c.n.holds(f.now) {
+		o = c.n
+	}
+	removed := f.value(n, o, label)
+	if !ok && !f.intersect {
+		return removed
+	}
+	tr := f.tr
+	for bit, e := range n.edges {
+		if e == nil {
+			continue
+		}
+		if !ok {
+			if f.reporting {
+				tr.reportDeleted(e.n, e.label)
+			}
+			removed += e.n.count
+			nodes, bits := e.n.stats(true)
+			tr.length -= nodes + 1
+			tr.bits -= bits + e.n.depth - n.depth
+			n.edges[bit] = nil
+			continue
+		}
+		cc, cok := c.seek(e.label, n.depth, e.n.depth)
+		r := f.pruneBinary(e.n, cc, cok, e.label)
+		e.n.count -= r
+		removed += r
+		if e.n.Value != nil {
+			continue
+		}
+		switch l, r := e.n.edges[0], e.n.edges[1]; {
+		case l == nil && r == nil:
+			n.edges[bit] = nil
+			tr.length--
+			tr.bits -= e.n.depth - n.depth
+		case l == nil:
+			n.edges[bit] = r
+			tr.length--
+		case r == nil:
+			n.edges[bit] = l
+			tr.length--
+		}
+	}
+	return removed
+}
+
+// stats returns the number of nodes below n and the size of their labels,
+// which is measured in bits for binary trees.
+func (n *Node) stats(binary bool) (nodes, size int) {
+	for _, e := range n.edges {
+		if e == nil {
+			continue
+		}
+		nn, s := e.n.stats(binary)
+		nodes += nn + 1
+		if binary {
+			size += s + e.n.depth - n.depth
+		} else {
+			size += s + len(e.label)
+		}
+	}
+	return nodes, size
+}
+
+// cursor is a position in a prefix tree.
+type cursor struct {
+	n    *Node
+	rest string // end of the label of the edge that leads to n that wasn't read yet
+}
+
+// next moves the cursor by label. If the tree holds no labels
+// that continue with label, it returns false.
+func (c cursor) next(label string) (cursor, bool) {
+	for label != "" {
+		if c.rest == "" {
+			var next *edge
+			for _, e := range c.n.lookup(label[0]) {
+				if strings.HasPrefix(label, e.label) || strings.HasPrefix(e.label, label) {
+					next = e
+					break
+				}
+			}
+			if next == nil {
+				return c, false
+			}
+			c = cursor{n: next.n, rest: next.label}
+		}
+		switch {
+		case strings.HasPrefix(label, c.rest):
+			label = label[len(c.rest):]
+			c.rest = ""
+		case strings.HasPrefix(c.rest, label):
+			c.rest = c.rest[len(label):]
+			label = ""
+		default:
+			return c, false
+		}
+	}
+	return c, true
+}
+
+// binCursor is a position in a binary tree, where n is the topmost node
+// whose label starts with the bits read so far and label leads to n.
+type binCursor struct {
+	n     *Node
+	label string
+}
+
+// seek moves the cursor from the from-th bit to the nbits-th bit of label.
+// If the tree holds no labels that start with those bits, it returns false.
+func (c binCursor) seek(label string, from, nbits int) (binCursor, bool) {
+	for {
+		end := c.n.depth
+		if nbits < end {
+			end = nbits
+		}
+		if from < end && commonBits(c.label, label, from, end) != end {
+			return c, false
+		}
+		if c.n.depth >= nbits {
+			return c, true
+		}
+		e := c.n.edges[bitAt(label, c.n.depth)]
+		if e == nil {
+			return c, false
+		}
+		from = c.n.depth
+		c = binCursor{n: e.n, label: e.label}
+	}
+}
@@ -0,0 +1,208 @@
+package radix_test
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestSetOperations(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	var words []string
+	for i := 0; i < 400; i++ {
+		words = append(words, strconv.Itoa(rnd.Intn(100000)))
+	}
+	words = append(words, "romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus",
+		"日本", "日本語", "日月", "áb", "ác", "a", "ab", "abc")
+	in := func(ws []string, w string) bool {
+		for _, x := range ws {
+			if x == w {
+				return true
+			}
+		}
+		return false
+	}
+	resolve := func(key string, a, b interface{}) interface{} {
+		return a.(string) + "+" + b.(string)
+	}
+	testCases := []struct {
+		flags, otherFlags int
+	}{
+		{0, 0},
+		{Trune, Trune},
+		{Tbinary, Tbinary},
+		{Tpool, 0},
+		{0, Tfold},
+	}
+	for _, tc := range testCases {
+		for i := 0; i < 5; i++ {
+			t.Run("", func(t *testing.T) {
+				var as, bs []string
+				for _, w := range words {
+					switch rnd.Intn(3) {
+					case 0:
+						as = append(as, w)
+					case 1:
+						bs = append(bs, w)
+					default:
+						as = append(as, w)
+						bs = append(bs, w)
+					}
+				}
+				build := func(flags int, ws []string, suffix string) *Tree {
+					tr := New(flags | Tnocolor)
+					for _, w := range ws {
+						tr.Add(w, w+suffix)
+					}
+					return tr
+				}
+				check := func(tr *Tree, want map[string]string) {
+					t.Helper()
+					if want, got := len(want), tr.Count(); want != got {
+						t.Fatalf("want %d values, got %d", want, got)
+					}
+					for w, v := range want {
+						if n, _ := tr.Get(w); n == nil || n.Value != v {
+							t.Fatalf("want %q to hold %q, got %v", w, v, n)
+						}
+					}
+					for i := 0; i < len(want); i++ { // counts of subtrees are kept
+						if key, _ := tr.Select(i); want[key] == "" {
+							t.Fatalf("want a label at %d, got %q", i, key)
+						}
+					}
+					exp := New(tc.flags | Tnocolor)
+					for w, v := range want {
+						exp.Add(w, v)
+					}
+					if want, got := exp.Len(), tr.Len(); want != got {
+						t.Errorf("want %d nodes, got %d", want, got)
+					}
+					if want, got := exp.Size(), tr.Size(); want != got {
+						t.Errorf("want size %d, got %d", want, got)
+					}
+					exp.Sort(AscLabelSort)
+					tr.Sort(AscLabelSort)
+					if want, got := exp.String(), tr.String(); want != got {
+						t.Errorf("want %q, got %q", want, got)
+					}
+				}
+
+				tr := build(tc.flags, as, "a")
+				tr.Merge(build(tc.otherFlags, bs, "b"), resolve)
+				want := make(map[string]string)
+				for _, w := range as {
+					want[w] = w + "a"
+				}
+				for _, w := range bs {
+					if in(as, w) {
+						want[w] = w + "a+" + w + "b"
+					} else {
+						want[w] = w + "b"
+					}
+				}
+				check(tr, want)
+
+				tr = build(tc.flags, as, "a")
+				tr.Intersect(build(tc.otherFlags, bs, "b"), resolve)
+				want = make(map[string]string)
+				for _, w := range as {
+					if in(bs, w) {
+						want[w] = w + "a+" + w + "b"
+					}
+				}
+				check(tr, want)
+
+				tr = build(tc.flags, as, "a")
+				tr.Difference(build(tc.otherFlags, bs, "b"))
+				want = make(map[string]string)
+				for _, w := range as {
+					if !in(bs, w) {
+						want[w] = w + "a"
+					}
+				}
+				check(tr, want)
+			})
+		}
+	}
+
+	// Without resolving them, values of the tree are kept.
+	tr, other := New(0), New(0)
+	tr.Add("romane", 1)
+	other.Add("romane", 2)
+	tr.Intersect(other, nil)
+	if n, _ := tr.Get("romane"); n == nil || n.Value != 1 {
+		t.Errorf("want %d, got %v", 1, n)
+	}
+}
+
+func TestSetOperationsSelf(t *testing.T) {
+	tr := New(Tsafe)
+	tr.Add("romane", 1)
+	tr.Add("romanus", 2)
+	tr.Merge(tr, func(key string, a, b interface{}) interface{} {
+		return a.(int) + b.(int)
+	})
+	if n, _ := tr.Get("romanus"); n == nil || n.Value != 4 {
+		t.Errorf("want %d, got %v", 4, n)
+	}
+	tr.Difference(tr)
+	if want, got := 0, tr.Count(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if want, got := 1, tr.Len(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
+
+func TestSetOperationsBinary(t *testing.T) {
+	for _, flags := range []int{Tbinary, Tbinary | Tfold} { // the latter isn't compatible
+		build := func(v string) *Tree {
+			tr := New(flags)
+			tr.AddBits(nil, 0, v)
+			tr.AddBits([]byte{0xA0}, 4, v)
+			return tr
+		}
+		tr := New(Tbinary)
+		tr.AddBits([]byte{0xA0}, 8, "a")
+		events, cancel := tr.Watch("")
+		tr.Merge(build("b"), nil)
+		for _, tc := range []struct {
+			key   []byte
+			nbits int
+			v     interface{}
+		}{
+			{nil, 0, "b"},
+			{[]byte{0xA0}, 4, "b"},
+			{[]byte{0xA0}, 8, "a"},
+		} {
+			if n := tr.GetBits(tc.key, tc.nbits); n == nil || n.Value != tc.v {
+				t.Errorf("want %v at /%d, got %v", tc.v, tc.nbits, n)
+			}
+		}
+		if want, got := 3, tr.Count(); want != got {
+			t.Errorf("want %d, got %d", want, got)
+		}
+		tr.Difference(build("c"))
+		if n := tr.GetBits([]byte{0xA0}, 8); n == nil || n.Value != "a" {
+			t.Errorf("want %q at /8, got %v", "a", n)
+		}
+		if want, got := 1, tr.Count(); want != got {
+			t.Errorf("want %d, got %d", want, got)
+		}
+		for _, w := range []Event{
+			{Type: AddEvent, Key: "", New: "b"},
+			{Type: AddEvent, Key: "\xa0", New: "b"},
+			{Type: DeleteEvent, Key: "", Old: "b"},
+			{Type: DeleteEvent, Key: "\xa0", Old: "b"},
+		} {
+			if got := receive(t, events); !reflect.DeepEqual(w, got) {
+				t.Errorf("want %+v, got %+v", w, got)
+			}
+		}
+		cancel()
+	}
+}
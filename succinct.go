@@ -0,0 +1,290 @@
+package radix
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Succinct is a read-only trie encoded with LOUDS (level-order unary degree sequence),
+// which describes the shape of the trie in about two bits per node.
+// Together with labels and values, the whole trie needs a few bits per node
+// plus the bytes of its labels, instead of the pointers and slices of nodes.
+//
+// Each label that holds a value has an ID between 0 and Len()-1.
+type Succinct struct {
+	louds  bitVector // degrees of nodes in level order, in unary
+	starts bitVector // starts of nodes' labels in labels
+	valued bitVector // nodes that hold values
+	firsts []byte    // first bytes of nodes' labels
+	labels []byte
+	values []interface{}
+	keys   map[int]string // original keys that differ from their stored labels
+
+	// Labels are transformed just like in the frozen tree.
+	fold      bool
+	reverse   bool
+	host      bool
+	delim     byte
+	normalize func(string) string
+}
+
+// Freeze encodes the tree into a succinct trie. Edges are sorted by label,
+// so labels are walked in ascending order of how they are stored.
+// Matching of placeholders and hostname patterns is not supported by succinct tries.
+//
+// Expired values are skipped. Binary trees can't be frozen, so nil is returned for them.
+func (tr *Tree) Freeze() *Succinct {
+	if tr.binary {
+		return nil
+	}
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	s := &Succinct{
+		firsts:    make([]byte, 0, tr.length),
+		labels:    make([]byte, 0, tr.size),
+		fold:      tr.fold,
+		reverse:   tr.reverse,
+		host:      tr.host,
+		delim:     tr.delim,
+		normalize: tr.normalize,
+	}
+	// Only keys of these trees may not be rebuilt from their stored labels.
+	lossy := tr.fold || tr.host || tr.normalize != nil
+	// The root is the only child of a virtual node.
+	s.louds.push(true)
+	s.louds.push(false)
+	now := time.Now().UnixNano()
+	queue := []*edge{{n: tr.root}}
+	for i := 0; i < len(queue); i++ {
+		e := queue[i]
+		edges := make([]*edge, len(e.n.edges))
+		copy(edges, e.n.edges)
+		sort.Slice(edges, func(i, j int) bool { return edges[i].label < edges[j].label })
+		for _, c := range edges {
+			s.louds.push(true)
+			queue = append(queue, c)
+		}
+		s.louds.push(false)
+		queue[i] = nil
+		var first byte
+		if e.label != "" {
+			first = e.label[0]
+		}
+		s.firsts = append(s.firsts, first)
+		for j := 0; j < len(e.label); j++ {
+			s.starts.push(j == 0)
+		}
+		s.labels = append(s.labels, e.label...)
+		valued := e.n.Value != nil && !e.n.expired(now)
+		s.valued.push(valued)
+		if valued {
+			if lossy && s.order(tr.key(e.n.key)) != e.n.key {
+				if s.keys == nil {
+					s.keys = make(map[int]string)
+				}
+				s.keys[len(s.values)] = e.n.key
+			}
+			s.values = append(s.values, e.n.Value)
+		}
+	}
+	s.louds.index()
+	s.starts.index()
+	s.valued.index()
+	return s
+}
+
+// Get retrieves the value of label.
+func (s *Succinct) Get(label string) (interface{}, bool) {
+	id := s.ID(label)
+	if id < 0 {
+		return nil, false
+	}
+	return s.values[id], true
+}
+
+// ID returns the ID of label, or -1 if label holds no value.
+func (s *Succinct) ID(label string) int {
+	if label == "" {
+		return -1
+	}
+	x, rest := s.seek(s.key(label))
+	if x < 0 || rest != "" || !s.valued.get(x) {
+		return -1
+	}
+	return s.valued.rank1(x)
+}
+
+// Key returns the label whose ID is id, as it was added to the frozen tree.
+func (s *Succinct) Key(id int) string {
+	if id < 0 || id >= len(s.values) {
+		return ""
+	}
+	if key, ok := s.keys[id]; ok {
+		return key
+	}
+	var labels []string
+	for x := s.valued.select1(id + 1); x > 0; x = s.parent(x) {
+		labels = append(labels, s.label(x))
+	}
+	var bd strings.Builder
+	for i := len(labels) - 1; i >= 0; i-- {
+		bd.WriteString(labels[i])
+	}
+	return s.order(bd.String())
+}
+
+// Len returns the number of labels that hold values.
+func (s *Succinct) Len() int {
+	return len(s.values)
+}
+
+// Walk calls fn for every label and value. See WalkPrefix for details.
+func (s *Succinct) Walk(fn func(key string, v interface{}) bool) {
+	s.WalkPrefix("", fn)
+}
+
+// WalkPrefix calls fn for every label that starts with prefix, in ascending order
+// of how labels are stored, passing labels the same way Key returns them.
+// If fn returns false, walking stops.
+//
+// Prefixes of reversed and hostname trees are reversed just like labels are,
+// so labels that end with prefix are walked instead. For hostname trees,
+// prefix is a domain, so "example.com" walks "api.example.com", but not "examples.com".
+func (s *Succinct) WalkPrefix(prefix string, fn func(key string, v interface{}) bool) {
+	prefix = s.key(prefix)
+	x, rest := s.seek(prefix)
+	if x < 0 {
+		return
+	}
+	if s.host && prefix != "" && rest == "" {
+		// Only subdomains of prefix follow it, after a delimiter.
+		if !s.visit(x, []byte(prefix), fn) {
+			return
+		}
+		first, count := s.children(x)
+		for c := first; c < first+count; c++ {
+			l := s.label(c)
+			if l[0] == s.delim && !s.walk(c, append([]byte(prefix), l...), fn) {
+				return
+			}
+		}
+		return
+	}
+	// The prefix may end in the middle of the node's label.
+	l := s.label(x)
+	if s.host && prefix != "" && rest[0] != s.delim {
+		return
+	}
+	buf := append([]byte(prefix), l[len(l)-len(rest):]...)
+	s.walk(x, buf, fn)
+}
+
+func (s *Succinct) walk(x int, buf []byte, fn func(key string, v interface{}) bool) bool {
+	if !s.visit(x, buf, fn) {
+		return false
+	}
+	first, count := s.children(x)
+	for c := first; c < first+count; c++ {
+		if !s.walk(c, append(buf, s.label(c)...), fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// visit calls fn for the value of node x, whose label is buf, if it holds any,
+// and returns false if fn does.
+func (s *Succinct) visit(x int, buf []byte, fn func(key string, v interface{}) bool) bool {
+	if !s.valued.get(x) {
+		return true
+	}
+	id := s.valued.rank1(x)
+	key, ok := s.keys[id]
+	if !ok {
+		key = s.order(string(buf))
+	}
+	return fn(key, s.values[id])
+}
+
+// seek returns the node that is reached after reading the whole label.
+// If label ends in the middle of a node's label, the remainder of
+// the node's label is also returned. If no node is reached, it returns -1.
+//
+// Labels of hostname trees are only read up to nodes that end at delimiters,
+// since nodes that share first bytes, such as "example" and "examples", may follow each other.
+func (s *Succinct) seek(label string) (int, string) {
+	x := 0
+	for label != "" {
+		first, count := s.children(x)
+		c := label[0]
+		i := first + sort.Search(count, func(i int) bool { return s.firsts[first+i] >= c })
+		next := -1
+		// Labels that start with the same byte are adjacent.
+		for ; i < first+count && s.firsts[i] == c; i++ {
+			l := s.label(i)
+			if strings.HasPrefix(label, l) && (!s.host || s.isDelimited(label, len(l))) {
+				next = i
+				label = label[len(l):]
+				break
+			}
+			if strings.HasPrefix(l, label) {
+				return i, l[len(label):]
+			}
+		}
+		if next < 0 {
+			return -1, ""
+		}
+		x = next
+	}
+	return x, ""
+}
+
+// isDelimited returns whether s can be split at i without
+// breaking any of the labels of a hostname.
+func (s *Succinct) isDelimited(label string, i int) bool {
+	return i >= len(label) || label[i] == s.delim || label[i-1] == s.delim
+}
+
+// children returns the first child of node x and its number of children.
+func (s *Succinct) children(x int) (int, int) {
+	start := s.louds.select0(x+1) + 1
+	end := s.louds.select0(x + 2)
+	return s.louds.rank1(start), end - start
+}
+
+func (s *Succinct) parent(x int) int {
+	return s.louds.rank0(s.louds.select1(x+1)) - 1
+}
+
+// label returns the label of the edge that leads to node x.
+func (s *Succinct) label(x int) string {
+	if x == 0 {
+		return ""
+	}
+	return b2s(s.labels[s.starts.select1(x):s.starts.select1(x+1)])
+}
+
+// key transforms label just like the frozen tree did.
+func (s *Succinct) key(label string) string {
+	if s.normalize != nil {
+		label = s.normalize(label)
+	}
+	if s.fold {
+		label = fold(label)
+	}
+	return s.order(label)
+}
+
+// order puts label's bytes in the same order they're stored in the trie.
+func (s *Succinct) order(label string) string {
+	switch {
+	case s.reverse:
+		return reverse(label)
+	case s.host:
+		return reverseHost(label, s.delim)
+	}
+	return label
+}
@@ -0,0 +1,186 @@
+package radix_test
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestSuccinct(t *testing.T) {
+	words := []string{
+		"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus",
+		"test", "toaster", "toasting", "slow", "slowly", "tom", "tomato", "tornado",
+		"a", "ab", "abc", "b", "áb", "ác", "日本", "日本語", "日月",
+	}
+	for i := 0; i < 3000; i++ {
+		words = append(words, strconv.Itoa(i*7919))
+	}
+	testCases := []int{0, Trune, Tfold, Treverse}
+	for _, flags := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(flags)
+			for i, w := range words {
+				tr.Add(w, i)
+			}
+			s := tr.Freeze()
+			if want, got := len(words), s.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			ids := make(map[int]bool)
+			for i, w := range words {
+				if v, ok := s.Get(w); !ok || v != i {
+					t.Fatalf("want %q to be found", w)
+				}
+				id := s.ID(w)
+				if id < 0 || id >= s.Len() || ids[id] {
+					t.Fatalf("want a unique ID for %q, got %d", w, id)
+				}
+				ids[id] = true
+				if want, got := w, s.Key(id); want != got {
+					t.Errorf("want %q, got %q", want, got)
+				}
+			}
+			for _, label := range []string{"", "r", "roman", "romanes", "日", "zzz", "tomatoes"} {
+				if _, ok := s.Get(label); ok {
+					t.Errorf("want %q not to be found", label)
+				}
+				if want, got := -1, s.ID(label); want != got {
+					t.Errorf("want %d, got %d", want, got)
+				}
+			}
+			if flags&Tfold > 0 {
+				if v, ok := s.Get("ROMANE"); !ok || v != 0 {
+					t.Errorf("want %q to be found", "ROMANE")
+				}
+			}
+
+			match := strings.HasPrefix
+			if flags&Treverse > 0 {
+				match = strings.HasSuffix
+			}
+			for _, prefix := range []string{"", "r", "rom", "roma", "romanus", "1", "99", "日", "e", "zzz"} {
+				var want, got []string
+				for _, w := range words {
+					if match(w, prefix) {
+						want = append(want, w)
+					}
+				}
+				s.WalkPrefix(prefix, func(key string, v interface{}) bool {
+					if words[v.(int)] != key {
+						t.Errorf("want %q, got %q", words[v.(int)], key)
+					}
+					got = append(got, key)
+					return true
+				})
+				if flags&Treverse == 0 && !sort.StringsAreSorted(got) {
+					t.Errorf("want keys to be sorted, got %v", got)
+				}
+				sort.Strings(want)
+				sort.Strings(got)
+				if strings.Join(want, ",") != strings.Join(got, ",") {
+					t.Errorf("want %v, got %v", want, got)
+				}
+			}
+			var n int
+			s.Walk(func(key string, v interface{}) bool {
+				n++
+				return n < 10
+			})
+			if want, got := 10, n; want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+		})
+	}
+	if s := New(Tbinary).Freeze(); s != nil {
+		t.Error("want binary trees not to be frozen")
+	}
+}
+
+func TestSuccinctExpired(t *testing.T) {
+	tr := New(0)
+	tr.Add("romane", 1)
+	tr.AddWithTTL("romanus", 2, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	s := tr.Freeze()
+	if want, got := 1, s.Len(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if _, ok := s.Get("romanus"); ok {
+		t.Errorf("want %q not to be found", "romanus")
+	}
+}
+
+func TestSuccinctKeys(t *testing.T) {
+	testCases := []struct {
+		flags  int
+		labels []string
+	}{
+		{Tfold, []string{"Romane", "romanus", "ΣΊΣΥΦΟΣ"}},
+		{Tfold | Treverse, []string{"Romane", "ROMANUS"}},
+		{Thost, []string{"api.example.com.", "example.com"}},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(tc.flags)
+			for i, label := range tc.labels {
+				tr.Add(label, i)
+			}
+			s := tr.Freeze()
+			for _, label := range tc.labels {
+				if want, got := label, s.Key(s.ID(label)); want != got {
+					t.Errorf("want %q, got %q", want, got)
+				}
+				if tc.flags&Tfold == 0 {
+					continue
+				}
+				if want, got := label, s.Key(s.ID(strings.ToUpper(label))); want != got {
+					t.Errorf("want %q, got %q", want, got)
+				}
+			}
+			s.Walk(func(key string, v interface{}) bool {
+				if want, got := tc.labels[v.(int)], key; want != got {
+					t.Errorf("want %q, got %q", want, got)
+				}
+				return true
+			})
+		})
+	}
+}
+
+func TestSuccinctWalkDomain(t *testing.T) {
+	tr := New(Thost)
+	for i, label := range []string{"example.com", "api.example.com", "examples.com", "www.examples.com", "example.org"} {
+		tr.Add(label, i)
+	}
+	s := tr.Freeze()
+	if v, ok := s.Get("examples.com"); !ok || v != 2 {
+		t.Errorf("want %d, got %v", 2, v)
+	}
+	testCases := []struct {
+		prefix string
+		want   []string
+	}{
+		{"example.com", []string{"example.com", "api.example.com"}},
+		{"examples.com", []string{"examples.com", "www.examples.com"}},
+		{"ample.com", nil},
+		{"com", []string{"example.com", "api.example.com", "examples.com", "www.examples.com"}},
+		{"", []string{"example.com", "api.example.com", "examples.com", "www.examples.com", "example.org"}},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var keys []string
+			s.WalkPrefix(tc.prefix, func(key string, _ interface{}) bool {
+				keys = append(keys, key)
+				return true
+			})
+			if want, got := tc.want, keys; !reflect.DeepEqual(want, got) {
+				t.Errorf("want %q, got %q", want, got)
+			}
+		})
+	}
+}
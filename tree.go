@@ -1,9 +1,9 @@
 package radix
 
 import (
-	"bytes"
 	"strings"
 	"sync"
+	"unicode/utf8"
 
 	"github.com/gbrlsnchs/color"
 )
@@ -17,19 +17,52 @@ const (
 	Tbinary
 	// Tnocolor disables colorful output.
 	Tnocolor
+	// Tfold makes labels case-insensitive by applying Unicode case folding.
+	Tfold
+	// Trune only splits edges on rune boundaries.
+	Trune
+	// Treverse stores labels reversed, which allows looking up labels by suffix.
+	// Edges of reversed trees are only split on rune boundaries and are printed
+	// as the labels they lead to, in their original order. Reversed trees don't support named labels.
+	Treverse
+	// Thost treats labels as hostnames, which are matched label by label
+	// starting from their top-level domains.
+	Thost
+	// Tpool batches allocations of edges and nodes in chunks, which reduces
+	// the number of allocations for large trees. It doesn't make nodes pointer-free:
+	// nodes are returned as pointers and hold values, keys and edges, so chunks
+	// are scanned by the garbage collector, and memory of deleted nodes
+	// is only released once their whole chunk is unused. Trees that no longer
+	// change can be encoded by MarshalFlat, whose layout holds no pointers.
+	Tpool
 )
 
+// WalkFunc is the function called for each node visited by (*Tree).Walk.
+type WalkFunc func(key string, n *Node) bool
+
 // Tree is a radix tree.
 type Tree struct {
 	root        *Node
 	length      int // total number of nodes
 	size        int // total byte size
+	bits        int // total bit size of binary trees
 	safe        bool
 	binary      bool
+	fold        bool
+	runes       bool
+	reverse     bool
+	host        bool
 	placeholder byte
 	delim       byte
+	normalize   func(string) string
+	pool        *pool
 	mu          *sync.RWMutex
 	bd          *builder
+	watchers    []*watcher
+	observers   []*observer
+	expiring    bool // whether values were added with TTLs
+	janitor     *janitor
+	bound       *bound
 }
 
 // New creates a named radix tree with a single node (its root).
@@ -46,9 +79,22 @@ func New(flags int) *Tree {
 		tr.mu = &sync.RWMutex{}
 		tr.safe = true
 	}
+	if flags&Tpool > 0 {
+		tr.pool = &pool{}
+	}
+	tr.fold = flags&Tfold > 0
+	tr.reverse = flags&Treverse > 0
+	if flags&Thost > 0 {
+		tr.host = true
+		tr.reverse = false
+		tr.placeholder = '@'
+		tr.delim = '.'
+	}
+	tr.runes = flags&Trune > 0 || tr.reverse
 	tr.bd = &builder{
 		Builder: &strings.Builder{},
 		debug:   flags&Tdebug > 0,
+		reverse: tr.reverse,
 	}
 	tr.bd.colors[colorRed] = color.New(color.CodeFgRed)
 	tr.bd.colors[colorGreen] = color.New(color.CodeFgGreen)
@@ -70,26 +116,54 @@ func (tr *Tree) Add(label string, v interface{}) {
 		defer tr.mu.Unlock()
 		tr.mu.Lock()
 	}
-	tnode := tr.root
+	tr.add(label, v, 0)
+}
+
+// add adds a new node to the tree without locking it.
+// If exp isn't zero, v expires at exp.
+func (tr *Tree) add(label string, v interface{}, exp int64) {
+	stored := tr.key(label)
+	tr.set(stored, len(stored)*8, label, v, exp)
+}
+
+// set adds v to the node of the first nbits bits of label, which is already transformed,
+// evicting labels first if the tree is bounded, and reports the change to watchers and observers.
+func (tr *Tree) set(label string, nbits int, key string, v interface{}, exp int64) {
+	if tr.bound != nil {
+		tr.makeRoom(label, nbits)
+	}
+	old := tr.put(label, nbits, key, v, exp)
+	if tr.bound != nil {
+		tr.bound.touch(tr.target(label, nbits))
+	}
+	tr.notifyAdd(label, key, old, v)
+}
+
+// put is like set, but it neither evicts labels nor reports the change.
+// It returns the node's previous value.
+func (tr *Tree) put(label string, nbits int, key string, v interface{}, exp int64) interface{} {
 	if tr.binary {
-		nn := tnode.addBinary(label, v)
-		tr.length += nn
-		tr.size += nn / 8
-		return
+		return tr.addBinary(label, nbits, key, v, exp)
 	}
+	return tr.insert(label, key, v, exp)
+}
+
+// insert adds v, which expires at exp, to the node of label,
+// which is already transformed, and returns the node's previous value.
+func (tr *Tree) insert(label, key string, v interface{}, exp int64) interface{} {
+	tnode := tr.root
+	var buf [16]*Node
+	path := append(buf[:0], tnode)
 	for {
 		var next *edge
 		var slice string
-		for _, edge := range tnode.edges {
+		for _, edge := range tnode.lookup(label[0]) {
 			var found int
 			slice = edge.label
-			for i := range slice {
-				if i < len(label) && slice[i] == label[i] {
-					found++
-					continue
-				}
-				break
+			for found < len(slice) && found < len(label) && slice[found] == label[found] {
+				found++
 			}
+			found = tr.cut(slice, label, found)
 			if found > 0 {
 				label = label[found:]
 				slice = slice[found:]
@@ -100,6 +174,7 @@ func (tr *Tree) Add(label string, v interface{}) {
 		if next != nil {
 			tnode = next.n
 			tnode.priority++
+			path = append(path, tnode)
 			// Match the whole word.
 			if len(label) == 0 {
 				// The label is exactly the same as the edge's label,
@@ -110,8 +185,14 @@ func (tr *Tree) Add(label string, v interface{}) {
 				// 	becomes
 				// 	(root) -> tnode("tomato", v2)
 				if len(slice) == 0 {
+					old := tnode.Value
+					if old == nil {
+						addCount(path, 1)
+					}
 					tnode.Value = v
-					return
+					tnode.key = key
+					tnode.expires = exp
+					return old
 				}
 				// The label is a prefix of the edge's label.
 				//
@@ -120,17 +201,18 @@ func (tr *Tree) Add(label string, v interface{}) {
 				// 	then add "tom"
 				// 	(root) -> ("tom", v2) -> ("ato", v1)
 				next.label = next.label[:len(next.label)-len(slice)]
-				c := tnode.clone()
-				c.priority--
-				tnode.edges = []*edge{
-					&edge{
-						label: slice,
-						n:     c,
-					},
-				}
+				tr.notifySplit(next.label, slice)
+				c := tr.pool.newEdge(slice, *tnode)
+				c.n.incrDepth()
+				c.n.priority--
+				tnode.edges = []*edge{c}
+				tnode.reindex()
+				addCount(path, 1)
 				tnode.Value = v
+				tnode.key = key
+				tnode.expires = exp
 				tr.length++
-				return
+				return nil
 			}
 			// Add a new node but break its parent into prefix and
 			// the remaining slice as a new edge.
@@ -141,41 +223,45 @@ func (tr *Tree) Add(label string, v interface{}) {
 			// 	(root) -> ("to", nil) -> ("mato", v1)
 			// 	                      +> ("rnado", v2)
 			if len(slice) > 0 {
-				c := tnode.clone()
-				c.priority--
+				c := tr.pool.newEdge(slice, *tnode) // the suffix that is cloned into a new node
+				c.n.incrDepth()
+				c.n.priority--
 				tnode.edges = []*edge{
-					&edge{ // the suffix that is clone into a new node
-						label: slice,
-						n:     c,
-					},
-					&edge{ // the new node
-						label: label,
-						n: &Node{
-							Value:    v,
-							depth:    tnode.depth + 1,
-							priority: 1,
-						},
-					},
+					c,
+					tr.pool.newEdge(label, Node{ // the new node
+						Value:    v,
+						key:      key,
+						expires:  exp,
+						depth:    tnode.depth + 1,
+						priority: 1,
+						count:    1,
+					}),
 				}
+				tnode.reindex()
+				addCount(path, 1)
 				next.label = next.label[:len(next.label)-len(slice)]
+				tr.notifySplit(next.label, slice)
 				tnode.Value = nil
+				tnode.key = ""
+				tnode.expires = 0
 				tr.length += 2
 				tr.size += len(label)
-				return
+				return nil
 			}
 			continue
 		}
-		tnode.edges = append(tnode.edges, &edge{
-			label: label,
-			n: &Node{
-				Value:    v,
-				depth:    tnode.depth + 1,
-				priority: 1,
-			},
-		})
+		tnode.addEdge(tr.pool.newEdge(label, Node{
+			Value:    v,
+			key:      key,
+			expires:  exp,
+			depth:    tnode.depth + 1,
+			priority: 1,
+			count:    1,
+		}))
+		addCount(path, 1)
 		tr.length++
 		tr.size += len(label)
-		return
+		return nil
 	}
 }
 
@@ -184,84 +270,105 @@ func (tr *Tree) Add(label string, v interface{}) {
 // If a parent node that holds no value ends up holding only one edge
 // after a deletion of one of its edges, it gets merged with the remaining edge.
 func (tr *Tree) Del(label string) {
-	if string(label) == "" {
+	if label == "" {
 		return
 	}
 	if tr.safe {
 		defer tr.mu.Unlock()
 		tr.mu.Lock()
 	}
-	tnode := tr.root
+	tr.del(label)
+}
+
+// del deletes a node without locking the tree.
+func (tr *Tree) del(label string) {
+	stored := tr.key(label)
+	tr.unset(stored, len(stored)*8)
+}
+
+// unset deletes the value of the node of the first nbits bits of label, which is already
+// transformed, and reports the change to watchers and observers.
+// It returns the deleted node's key and value.
+func (tr *Tree) unset(label string, nbits int) (string, interface{}) {
+	key, old := tr.drop(label, nbits)
+	if old != nil {
+		tr.notifyDel(label, key, old)
+	}
+	return key, old
+}
+
+// drop is like unset, but it doesn't report the change.
+func (tr *Tree) drop(label string, nbits int) (string, interface{}) {
+	if tr.bound != nil {
+		tr.bound.untrack(tr.target(label, nbits))
+	}
 	if tr.binary {
-		del := tnode.delBinary(label)
-		tr.length--
-		bits := tr.size*8 - del
-		if bits == 0 {
-			tr.size = 0
-			return
-		}
-		tr.size = (bits / 8) + 1
-		return
+		return tr.delBinary(label, nbits)
 	}
-	var edgex int
-	var parent *edge
-	var ptrs []*int
-	for tnode != nil && label != "" {
-		var next *edge
+	return tr.remove(label)
+}
+
+// remove deletes the value of the node of label, which is already transformed,
+// and returns the node's key and value.
+func (tr *Tree) remove(label string) (string, interface{}) {
+	tnode := tr.root
+	var (
+		parent *edge // edge that leads to pnode
+		next   *edge // edge that leads to tnode
+		pnode  *Node
+		buf    [16]*Node
+	)
+	path := buf[:0]
+	for label != "" {
+		var e *edge
 		// Look for exact matches.
-		for i, e := range tnode.edges {
-			if strings.HasPrefix(label, e.label) {
-				next = e
-				edgex = i
+		for _, c := range tnode.lookup(label[0]) {
+			if strings.HasPrefix(label, c.label) {
+				e = c
 				break
 			}
 		}
-		if next != nil {
-			tnode = next.n
-			label = label[len(next.label):]
-			ptrs = append(ptrs, &tnode.priority)
-			// While not the exact match, set the tnode's parent.
-			if label != "" {
-				parent = next
-			}
-			continue
-		}
 		// No matches.
-		parent = nil
-		tnode = nil
-	}
-	if tnode != nil {
-		pnode := tr.root // in case label matched in the first try
-		if parent != nil {
-			pnode = parent.n
+		if e == nil {
+			return "", nil
 		}
-		// Decrement the priority of upper nodes.
-		done := make(chan struct{})
-		if tnode.Value != nil {
-			go func() {
-				for _, p := range ptrs {
-					*p--
-				}
-				close(done)
-			}()
-		}
-		// Merge tnode's edges with the parent's.
-		pnode.edges = append(pnode.edges, tnode.edges...)
-		// Remove tnode from the parent, leaving only its edges behind.
-		pnode.edges = append(pnode.edges[:edgex], pnode.edges[edgex+1:]...)
+		parent, next = next, e
+		pnode, tnode = tnode, e.n
+		label = label[len(e.label):]
+		path = append(path, tnode)
+	}
+	if tnode.Value == nil {
+		return "", nil
+	}
+	// Decrement the priority of upper nodes.
+	for _, n := range path {
+		n.priority--
+	}
+	addCount(path, -1)
+	tr.root.count--
+	key, old := tnode.key, tnode.Value
+	tnode.Value = nil
+	tnode.key = ""
+	tnode.expires = 0
+	switch len(tnode.edges) {
+	case 0:
+		// Remove tnode from its parent.
+		pnode.delEdge(next)
+		tr.length--
+		tr.size -= len(next.label)
 		// When only one edge remained in pnode and its value is nil, they can be merged.
 		if len(pnode.edges) == 1 && pnode.Value == nil && parent != nil {
-			e := pnode.edges[0]
-			parent.label += e.label
-			pnode.Value = e.n.Value
-			pnode.edges = e.n.edges
+			tr.notifyMerge(parent.label, pnode.edges[0].label)
+			parent.merge()
 			tr.length--
 		}
+	case 1:
+		// Without a value, tnode is only a prefix of its only edge.
+		tr.notifyMerge(next.label, tnode.edges[0].label)
+		next.merge()
 		tr.length--
-		if tnode.Value != nil {
-			<-done
-		}
 	}
+	return key, old
 }
 
 // Get retrieves a node.
@@ -273,57 +380,54 @@ func (tr *Tree) Get(label string) (*Node, map[string]string) {
 		defer tr.mu.RUnlock()
 		tr.mu.RLock()
 	}
+	now := tr.now()
+	n, params, stored := tr.get(label, now)
+	if n != nil && n.expired(now) {
+		return nil, nil
+	}
+	if n != nil && tr.bound != nil {
+		tr.used(n, stored, len(params) == 0)
+	}
+	return n, params
+}
+
+// get retrieves a node without locking the tree, also returning label as it's stored.
+// Hostname patterns whose values expired at now are skipped in favor of less specific ones.
+func (tr *Tree) get(label string, now int64) (*Node, map[string]string, string) {
+	orig := tr.order(label)
+	label = tr.key(label)
+	full := label
 	tnode := tr.root
 	if tr.binary {
-		return tnode.getBinary(label), nil
+		return tnode.getBinary(label, len(label)*8), nil, label
+	}
+	if tr.host {
+		n, params := tr.getHost(label, orig, now)
+		return n, params, label
 	}
-	var params map[string]string
+	var (
+		buf [4]param
+		ok  bool
+	)
+	ps := buf[:0]
 	for tnode != nil && label != "" {
 		var next *edge
-	Walk:
-		for _, edge := range tnode.edges {
-			slice := edge.label
-			for {
-				phIndex := len(slice)
-				// Check if there are any placeholders.
-				// If there are none, then use the whole word for comparison.
-				if i := strings.IndexByte(slice, tr.placeholder); i >= 0 {
-					phIndex = i
-				}
-				prefix := slice[:phIndex]
-				// If "slice" (until placeholder) is not prefix of
-				// "label", then keep walking.
-				if !strings.HasPrefix(label, prefix) {
-					continue Walk
-				}
-				label = label[len(prefix):]
-				// If "slice" is the whole label,
-				// then the match is complete and the algorithm
-				// is ready to go to the next edge.
-				if len(prefix) == len(slice) {
-					next = edge
-					break Walk
-				}
-				// Check whether there is a delimiter.
-				// If there isn'tr, then use the whole world as parameter.
-				var delimIndex int
-				slice = slice[phIndex:]
-				if delimIndex = strings.IndexByte(slice[1:], tr.delim) + 1; delimIndex <= 0 {
-					delimIndex = len(slice)
-				}
-				key := slice[1:delimIndex] // remove the placeholder from the map key
-				slice = slice[delimIndex:]
-				if delimIndex = strings.IndexByte(label[1:], tr.delim) + 1; delimIndex <= 0 {
-					delimIndex = len(label)
-				}
-				if params == nil {
-					params = make(map[string]string)
-				}
-				params[key] = label[:delimIndex]
-				label = label[delimIndex:]
-				if slice == "" && label == "" {
-					next = edge
-					break Walk
+		for _, e := range tnode.lookup(label[0]) {
+			var rest string
+			if rest, ps, ok = tr.match(e.label, label, len(full), ps); ok {
+				next = e
+				label = rest
+				break
+			}
+		}
+		// Edges that start with a placeholder can match any label.
+		if next == nil && tr.placeholder != 0 && label[0] != tr.placeholder {
+			for _, e := range tnode.lookup(tr.placeholder) {
+				var rest string
+				if rest, ps, ok = tr.match(e.label, label, len(full), ps); ok {
+					next = e
+					label = rest
+					break
 				}
 			}
 		}
@@ -333,7 +437,182 @@ func (tr *Tree) Get(label string) (*Node, map[string]string) {
 		}
 		tnode = nil
 	}
-	return tnode, params
+	return tnode, tr.params(tnode, ps, full, orig), full
+}
+
+// match matches an edge's label against the beginning of label, appending named labels
+// to ps, where size is the length of the whole label being matched.
+// It returns what remains of label.
+func (tr *Tree) match(slice, label string, size int, ps []param) (string, []param, bool) {
+	if tr.placeholder == 0 { // static lookups only
+		if !strings.HasPrefix(label, slice) {
+			return label, ps, false
+		}
+		return label[len(slice):], ps, true
+	}
+	n := len(ps)
+	for {
+		phIndex := len(slice)
+		// Check if there are any placeholders.
+		// If there are none, then use the whole word for comparison.
+		if i := strings.IndexByte(slice, tr.placeholder); i >= 0 {
+			phIndex = i
+		}
+		prefix := slice[:phIndex]
+		// If "slice" (until placeholder) is not prefix of
+		// "label", then the edge doesn't match.
+		if !strings.HasPrefix(label, prefix) {
+			return label, ps[:n], false
+		}
+		label = label[len(prefix):]
+		// If "slice" is the whole label,
+		// then the match is complete and the algorithm
+		// is ready to go to the next edge.
+		if len(prefix) == len(slice) {
+			return label, ps, true
+		}
+		if label == "" { // named labels can't be empty
+			return label, ps[:n], false
+		}
+		// Check whether there is a delimiter.
+		// If there isn't, then use the whole word as parameter.
+		var delimIndex int
+		slice = slice[phIndex:]
+		if delimIndex = strings.IndexByte(slice[1:], tr.delim) + 1; delimIndex <= 0 {
+			delimIndex = len(slice)
+		}
+		key := slice[1:delimIndex] // remove the placeholder from the map key
+		slice = slice[delimIndex:]
+		if delimIndex = strings.IndexByte(label[1:], tr.delim) + 1; delimIndex <= 0 {
+			delimIndex = len(label)
+		}
+		ps = append(ps, param{key: key, value: label[:delimIndex], off: size - len(label)})
+		label = label[delimIndex:]
+		if slice == "" && label == "" {
+			return label, ps, true
+		}
+	}
+}
+
+// params maps the named labels matched by n's pattern to their values, where label
+// is the label that was matched and orig is how it was passed, in the same order
+// bytes are stored in the tree.
+//
+// Names are taken from the label the pattern was added with, and values from orig,
+// unless a normalizer changed it, in which case values are returned normalized.
+func (tr *Tree) params(n *Node, ps []param, label, orig string) map[string]string {
+	if n == nil || len(ps) == 0 {
+		return nil
+	}
+	var names []string
+	if tr.fold || tr.normalize != nil {
+		// Stored patterns are transformed just like labels are.
+		if names = tr.paramNames(tr.order(n.key)); len(names) != len(ps) {
+			names = nil
+		}
+	}
+	var offs []int
+	if label != orig && tr.normalize == nil {
+		offs = foldOffsets(orig)
+	}
+	params := make(map[string]string, len(ps))
+	for i, p := range ps {
+		key, value := p.key, p.value
+		if names != nil {
+			key = names[i]
+		}
+		switch {
+		case label == orig:
+			value = orig[p.off : p.off+len(p.value)]
+		case offs != nil:
+			value = orig[offs[p.off]:offs[p.off+len(p.value)]]
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// paramNames returns the names of the named labels of pattern,
+// whose bytes are in the same order they're stored in the tree.
+func (tr *Tree) paramNames(pattern string) []string {
+	var names []string
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != tr.placeholder || tr.host && i > 0 && pattern[i-1] != tr.delim {
+			continue
+		}
+		end := strings.IndexByte(pattern[i+1:], tr.delim) + i + 1
+		if end <= i {
+			end = len(pattern)
+		}
+		names = append(names, pattern[i+1:end])
+		i = end
+	}
+	return names
+}
+
+// HasPrefix returns whether the tree holds any label that starts with prefix.
+//
+// Note that this doesn't work with reversed or hostname trees. For reversed trees, use HasSuffix.
+func (tr *Tree) HasPrefix(prefix string) bool {
+	if tr.reverse || tr.host {
+		return false
+	}
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	prefix = tr.key(prefix)
+	if tr.binary {
+		n := tr.root.seekBinary(prefix, len(prefix)*8)
+		return n != nil && n.holdsAny(tr.now())
+	}
+	return tr.root.hasPrefix(prefix, tr.now())
+}
+
+// HasSuffix returns whether the tree holds any label that ends with suffix.
+//
+// Note that this only works with reversed trees.
+func (tr *Tree) HasSuffix(suffix string) bool {
+	if !tr.reverse || tr.binary {
+		return false
+	}
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	return tr.root.hasPrefix(tr.key(suffix), tr.now())
+}
+
+// LongestPrefix retrieves the node with the longest label that is a prefix of label.
+//
+// Note that this doesn't work with reversed or hostname trees. For reversed trees, use LongestSuffix.
+func (tr *Tree) LongestPrefix(label string) *Node {
+	if tr.reverse || tr.host {
+		return nil
+	}
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	label = tr.key(label)
+	if tr.binary {
+		return tr.root.longestPrefixBinary(label, len(label)*8, tr.now())
+	}
+	return tr.root.longestPrefix(label, tr.now())
+}
+
+// LongestSuffix retrieves the node with the longest label that is a suffix of label.
+//
+// Note that this only works with reversed trees.
+func (tr *Tree) LongestSuffix(label string) *Node {
+	if !tr.reverse || tr.binary {
+		return nil
+	}
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	return tr.root.longestPrefix(tr.key(label), tr.now())
 }
 
 // Len returns the total numbers of nodes,
@@ -346,18 +625,58 @@ func (tr *Tree) Len() int {
 	return tr.length
 }
 
+// SetNormalizer sets a function that normalizes labels before
+// they are compared, e.g. for applying Unicode normalization forms.
+//
+// When used together with Tfold, labels are normalized before being case folded.
+// Nodes still keep the label they were added with, which is returned by (*Node).Key.
+// Values of named labels are returned normalized, but their names aren't.
+func (tr *Tree) SetNormalizer(fn func(string) string) {
+	tr.normalize = fn
+}
+
 // SetBoundaries sets a placeholder and a delimiter for
 // the tree to be able to search for named labels.
+//
+// Hostname trees use '@' and '.' by default and always keep '.' as their delimiter.
+// Since patterns of reversed trees are stored reversed, it does nothing for them.
 func (tr *Tree) SetBoundaries(placeholder, delim byte) {
+	if tr.reverse {
+		return
+	}
 	tr.placeholder = placeholder
-	tr.delim = delim
+	if !tr.host {
+		tr.delim = delim
+	}
 }
 
 // Size returns the total byte size stored in the tree.
 func (tr *Tree) Size() int {
+	if tr.binary {
+		return (tr.bits + 7) / 8
+	}
 	return tr.size
 }
 
+// Walk walks the tree calling fn for every node that holds a value.
+// If fn returns false, walking stops.
+//
+// Nodes are visited in the order their edges are sorted and
+// keys are the labels the nodes were added with.
+func (tr *Tree) Walk(fn WalkFunc) {
+	if tr.safe {
+		defer tr.mu.RUnlock()
+		tr.mu.RLock()
+	}
+	if tr.expiring {
+		fn = live(fn)
+	}
+	if tr.root.Value != nil && !fn(tr.root.key, tr.root) { // only binary trees have roots with values
+		return
+	}
+	tr.root.walk(fn)
+}
+
 // Sort sorts the tree nodes and its children recursively
 // according to their priority lengther.
 func (tr *Tree) Sort(st SortingTechnique) {
@@ -389,9 +708,48 @@ func (tr *Tree) String() string {
 	}
 	tr.bd.WriteByte('\n')
 	if tr.binary {
-		tr.root.writeToBinary(tr.bd, &bytes.Buffer{}, &bytes.Buffer{})
+		tr.root.writeToBinary(tr.bd, "")
 	} else {
 		tr.root.writeTo(tr.bd)
 	}
 	return tr.bd.String()
 }
+
+// key returns label as it's stored in the tree.
+func (tr *Tree) key(label string) string {
+	if tr.normalize != nil {
+		label = tr.normalize(label)
+	}
+	if tr.fold {
+		label = fold(label)
+	}
+	return tr.order(label)
+}
+
+// order puts label's bytes in the same order they're stored in the tree.
+func (tr *Tree) order(label string) string {
+	switch {
+	case tr.reverse:
+		return reverse(label)
+	case tr.host:
+		return reverseHost(label, tr.delim)
+	}
+	return label
+}
+
+// cut returns the offset up to which an edge's label can be split
+// when it shares its first i bytes with a new label.
+func (tr *Tree) cut(slice, label string, i int) int {
+	if tr.runes {
+		for i > 0 && (i < len(slice) && !utf8.RuneStart(slice[i]) ||
+			i < len(label) && !utf8.RuneStart(label[i])) {
+			i--
+		}
+	}
+	if tr.host {
+		for i > 0 && (!tr.isDelimited(slice, i) || !tr.isDelimited(label, i)) {
+			i--
+		}
+	}
+	return i
+}
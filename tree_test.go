@@ -201,30 +201,69 @@ func TestTree(t *testing.T) {
 	}
 }
 
+func TestLongestPrefix(t *testing.T) {
+	tr := New(0)
+	for i, label := range []string{"/", "/api", "/api/users", "/static/"} {
+		tr.Add(label, i)
+	}
+	testCases := []struct {
+		label  string
+		key    string
+		prefix bool
+	}{
+		{label: "/api/users/123", key: "/api/users", prefix: false},
+		{label: "/api/user", key: "/api", prefix: true},
+		{label: "/static/css/main.css", key: "/static/", prefix: false},
+		{label: "/stat", key: "/", prefix: true},
+		{label: "/", key: "/", prefix: true},
+		{label: "foo", key: "", prefix: false},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			var key string
+			if n := tr.LongestPrefix(tc.label); n != nil {
+				key = n.Key()
+			}
+			if want, got := tc.key, key; want != got {
+				t.Errorf("want %q, got %q", want, got)
+			}
+			if want, got := tc.prefix, tr.HasPrefix(tc.label); want != got {
+				t.Errorf("want %t, got %t", want, got)
+			}
+		})
+	}
+}
+
 func TestBinaryTree(t *testing.T) {
 	testCases := []struct {
 		labels []string
 		values []interface{}
+		length int
 	}{
 		{
 			labels: []string{"foobar"},
 			values: []interface{}{"bazqux"},
+			length: 2,
 		},
 		{
 			labels: []string{"foo", "bar"},
 			values: []interface{}{"baz", "qux"},
+			length: 4,
 		},
 		{
 			labels: []string{"abc", "d"},
 			values: []interface{}{"foo", "bar"},
+			length: 4,
 		},
 		{
 			labels: []string{"a", "abc", "d"},
 			values: []interface{}{"foo", "bar", "baz"},
+			length: 5,
 		},
 		{
 			labels: []string{"foo", "bar", "baz", "qux"},
 			values: []interface{}{1, 12, 123, 1234},
+			length: 8,
 		},
 		{
 			labels: []string{
@@ -236,6 +275,7 @@ func TestBinaryTree(t *testing.T) {
 				"dogs",
 			},
 			values: []interface{}{1, 2, 3, 4, 5, 6},
+			length: 11,
 		},
 	}
 	for _, tc := range testCases {
@@ -256,6 +296,28 @@ func TestBinaryTree(t *testing.T) {
 					t.Errorf("want %d, got %d", want, got)
 				}
 			}
+			if want, got := tc.length, tr.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+
+			for i, label := range tc.labels {
+				tr.Del(label)
+				if n, _ := tr.Get(label); n != nil && n.Value != nil {
+					t.Errorf("want %q to be deleted", label)
+				}
+				for j, label := range tc.labels[i+1:] {
+					n, _ := tr.Get(label)
+					if want, got := tc.values[i+j+1], n.Value; !reflect.DeepEqual(want, got) {
+						t.Errorf("want %v, got %v", want, got)
+					}
+				}
+			}
+			if want, got := 1, tr.Len(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			if want, got := 0, tr.Size(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
 		})
 	}
 }
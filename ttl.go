@@ -0,0 +1,246 @@
+package radix
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnsafe is returned when starting a janitor for a tree that isn't safe for concurrent use.
+	ErrUnsafe = errors.New("radix: tree is not safe for concurrent use")
+	// ErrInterval is returned when starting a janitor with an interval that isn't positive.
+	ErrInterval = errors.New("radix: non-positive interval")
+)
+
+// AddWithTTL adds a new node to the tree whose value expires after ttl.
+// If ttl isn't positive, the value never expires, just like when added with Add.
+//
+// Expired values are skipped by every method that retrieves, matches, counts, compares
+// or exports values, but they're only deleted by DelExpired, ExpirePrefix or the tree's janitor,
+// so their nodes are still counted by Len and Size until then.
+func (tr *Tree) AddWithTTL(label string, v interface{}, ttl time.Duration) {
+	if label == "" || v == nil {
+		return
+	}
+	var exp int64
+	if ttl > 0 {
+		exp = time.Now().Add(ttl).UnixNano()
+	}
+	if tr.safe {
+		defer tr.mu.Unlock()
+		tr.mu.Lock()
+	}
+	tr.expiring = tr.expiring || exp != 0
+	tr.add(label, v, exp)
+}
+
+// Expiry returns when the node's value expires, or the zero time if it never does.
+func (n *Node) Expiry() time.Time {
+	if n.expires == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n.expires)
+}
+
+func (n *Node) expired(now int64) bool {
+	return n.expires != 0 && n.expires <= now
+}
+
+// now returns the time at which values are checked for expiry, which is 0,
+// for which no value is expired, if no value of the tree was added with a TTL.
+func (tr *Tree) now() int64 {
+	if !tr.expiring {
+		return 0
+	}
+	return time.Now().UnixNano()
+}
+
+// holds returns whether n holds a value that isn't expired at now.
+func (n *Node) holds(now int64) bool {
+	return n.Value != nil && !n.expired(now)
+}
+
+// holdsAny returns whether n's subtree holds any value that isn't expired at now.
+func (n *Node) holdsAny(now int64) bool {
+	if now == 0 { // every edge leads to a value
+		return n.Value != nil || !n.IsLeaf()
+	}
+	if n.holds(now) {
+		return true
+	}
+	for _, e := range n.edges {
+		if e != nil && e.n.holdsAny(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// countAt returns how many values of n's subtree aren't expired at now.
+// Unless now is 0, it walks the whole subtree.
+func (n *Node) countAt(now int64) int {
+	if now == 0 {
+		return n.count
+	}
+	var c int
+	if n.holds(now) {
+		c++
+	}
+	for _, e := range n.edges {
+		if e != nil {
+			c += e.n.countAt(now)
+		}
+	}
+	return c
+}
+
+// live wraps fn so that it isn't called for expired values.
+func live(fn WalkFunc) WalkFunc {
+	now := time.Now().UnixNano()
+	return func(key string, n *Node) bool {
+		return n.expired(now) || fn(key, n)
+	}
+}
+
+// janitor deletes expired values of a tree periodically.
+type janitor struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartJanitor starts a goroutine that deletes expired values every interval until Close is called.
+// If the janitor is already running, it does nothing.
+//
+// Since the janitor changes the tree concurrently, it only works with safe trees,
+// so ErrUnsafe is returned for other trees. Unsafe trees can call DelExpired instead.
+func (tr *Tree) StartJanitor(interval time.Duration) error {
+	if !tr.safe {
+		return ErrUnsafe
+	}
+	if interval <= 0 {
+		return ErrInterval
+	}
+	defer tr.mu.Unlock()
+	tr.mu.Lock()
+	if tr.janitor != nil {
+		return nil
+	}
+	j := &janitor{stop: make(chan struct{}), done: make(chan struct{})}
+	tr.janitor = j
+	go j.run(tr, interval)
+	return nil
+}
+
+// Close stops the tree's janitor, if it's running, and waits for it to return.
+func (tr *Tree) Close() {
+	if !tr.safe {
+		return
+	}
+	tr.mu.Lock()
+	j := tr.janitor
+	tr.janitor = nil
+	tr.mu.Unlock()
+	if j != nil {
+		close(j.stop)
+		<-j.done
+	}
+}
+
+func (j *janitor) run(tr *Tree, interval time.Duration) {
+	defer close(j.done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			tr.DelExpired()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// DelExpired deletes every expired value and returns how many values were deleted.
+func (tr *Tree) DelExpired() int {
+	if tr.safe {
+		defer tr.mu.Unlock()
+		tr.mu.Lock()
+	}
+	if !tr.expiring {
+		return 0
+	}
+	now := time.Now().UnixNano()
+	return tr.expire("", func(n *Node) bool { return n.expired(now) })
+}
+
+// ExpirePrefix deletes every value whose label starts with prefix, whether it has expired or not,
+// and returns how many values were deleted. Deleted values are reported to watchers and observers.
+//
+// Prefixes are transformed just like labels are, so labels of reversed
+// and hostname trees that end with prefix are deleted instead.
+func (tr *Tree) ExpirePrefix(prefix string) int {
+	if tr.safe {
+		defer tr.mu.Unlock()
+		tr.mu.Lock()
+	}
+	return tr.expire(tr.key(prefix), func(*Node) bool { return true })
+}
+
+// expire deletes the values for which fn returns true whose labels,
+// which are transformed, start with prefix.
+func (tr *Tree) expire(prefix string, fn func(n *Node) bool) int {
+	var ts []target
+	if tr.root.Value != nil && prefix == "" && fn(tr.root) { // only binary trees have roots with values
+		ts = append(ts, target{})
+	}
+	ts = tr.collect(tr.root, nil, prefix, fn, ts)
+	for _, t := range ts {
+		tr.unset(t.label, t.nbits)
+	}
+	return len(ts)
+}
+
+// target is the transformed label of a node that holds a value.
+type target struct {
+	label string
+	nbits int
+}
+
+// target returns the target of the first nbits bits of label, which is already transformed.
+func (tr *Tree) target(label string, nbits int) target {
+	if tr.binary && len(label)*8 != nbits {
+		label = maskBits([]byte(label), nbits)
+	}
+	return target{label, nbits}
+}
+
+// collect appends to ts the labels of nodes below n that hold values for which
+// fn returns true and whose labels start with prefix, where label is n's label.
+func (tr *Tree) collect(n *Node, label []byte, prefix string, fn func(n *Node) bool, ts []target) []target {
+	for _, e := range n.edges {
+		if e == nil { // binary trees hold empty edges
+			continue
+		}
+		var next []byte
+		if tr.binary {
+			// Edges of binary trees hold whole labels, but only
+			// bits within their nodes' depths are relevant.
+			next = append(label[:0], e.label[:e.n.depth/8]...)
+		} else {
+			next = append(label, e.label...)
+		}
+		if l := b2s(next); !strings.HasPrefix(l, prefix) && !strings.HasPrefix(prefix, l) {
+			continue
+		}
+		if e.n.Value != nil && len(next) >= len(prefix) && fn(e.n) {
+			if tr.binary {
+				ts = append(ts, tr.target(e.label, e.n.depth))
+			} else {
+				ts = append(ts, target{string(next), len(next) * 8})
+			}
+		}
+		ts = tr.collect(e.n, next, prefix, fn, ts)
+	}
+	return ts
+}
@@ -0,0 +1,310 @@
+package radix_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestAddWithTTL(t *testing.T) {
+	testCases := []int{0, Tsafe, Tbinary, Tfold, Treverse, Thost, Tpool}
+	for _, flags := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(flags)
+			tr.AddWithTTL("romane", 1, time.Hour)
+			tr.AddWithTTL("romanus", 2, time.Nanosecond)
+			tr.AddWithTTL("romulus", 3, 0)
+			time.Sleep(time.Millisecond)
+
+			if n, _ := tr.Get("romanus"); n != nil {
+				t.Errorf("want %q to be expired, got %v", "romanus", n.Value)
+			}
+			n, _ := tr.Get("romane")
+			if n == nil || n.Expiry().IsZero() {
+				t.Fatalf("want %q to expire later, got %v", "romane", n)
+			}
+			if n, _ := tr.Get("romulus"); n == nil || !n.Expiry().IsZero() {
+				t.Errorf("want %q to never expire, got %v", "romulus", n)
+			}
+			var keys []string
+			tr.Walk(func(key string, _ *Node) bool {
+				keys = append(keys, key)
+				return true
+			})
+			if want, got := 2, len(keys); want != got {
+				t.Errorf("want %d, got %d (%q)", want, got, keys)
+			}
+
+			// Expired values aren't counted, even before they're deleted.
+			if want, got := 2, tr.Count(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			if flags&(Treverse|Thost) == 0 {
+				if key, _ := tr.Select(1); key != "romulus" {
+					t.Errorf("want %q, got %q", "romulus", key)
+				}
+				if want, got := 1, tr.Rank("romulus"); want != got {
+					t.Errorf("want %d, got %d", want, got)
+				}
+			}
+			if want, got := 1, tr.DelExpired(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			if want, got := 2, tr.Count(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+
+			// Adding without a TTL removes the expiry.
+			tr.Add("romane", 4)
+			if n, _ := tr.Get("romane"); n == nil || !n.Expiry().IsZero() {
+				t.Errorf("want %q to never expire, got %v", "romane", n)
+			}
+			tr.AddWithTTL("romanus", 5, time.Hour)
+			if n, _ := tr.Get("romanus"); n == nil || n.Value != 5 {
+				t.Errorf("want %d, got %v", 5, n)
+			}
+		})
+	}
+}
+
+func TestExpiredPrefixes(t *testing.T) {
+	for _, flags := range []int{0, Tbinary, Treverse} {
+		tr := New(flags)
+		tr.AddWithTTL("/a", 1, time.Nanosecond)
+		time.Sleep(time.Millisecond)
+		label, affix := "/a/b", "/a"
+		longest, has := tr.LongestPrefix, tr.HasPrefix
+		if flags&Treverse > 0 {
+			label, affix = "/b/a", "/a"
+			longest, has = tr.LongestSuffix, tr.HasSuffix
+		}
+		if n := longest(label); n != nil {
+			t.Errorf("want %q not to be matched, got %v", label, n.Value)
+		}
+		if has(affix) || has("/") {
+			t.Errorf("want %q not to be matched", affix)
+		}
+		if want, got := 0, tr.Count(); want != got {
+			t.Errorf("want %d, got %d", want, got)
+		}
+		if key, v := tr.Select(0); key != "" || v != nil {
+			t.Errorf("want no label, got %q", key)
+		}
+		if want, got := 0, tr.Rank("/b"); want != got {
+			t.Errorf("want %d, got %d", want, got)
+		}
+	}
+
+	tr := New(Tbinary)
+	tr.AddBits([]byte{0xA0}, 4, 1)
+	tr.AddWithTTL("\xa0", 2, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if n := tr.LongestPrefixBits([]byte{0xA0}, 8); n == nil || n.Value != 1 {
+		t.Errorf("want %d, got %v", 1, n)
+	}
+	if tr.HasPrefixBits([]byte{0xA0}, 5) {
+		t.Errorf("want no prefix to be held")
+	}
+
+	// Expired values are neither minimized nor compared.
+	old, new := New(0), New(0)
+	old.Add("romane", 1)
+	new.AddWithTTL("romane", 1, time.Nanosecond)
+	new.AddWithTTL("romanus", 2, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if want, got := 0, new.Minimize().Len(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	want := []Change{{Type: RemovedChange, Key: "romane", Old: 1}}
+	if got := Diff(old, new, nil); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestExpirePrefix(t *testing.T) {
+	testCases := []struct {
+		flags  int
+		prefix string
+		want   []string
+	}{
+		{0, "roman", []string{"romulus", "rubens"}},
+		{Tsafe, "rom", []string{"rubens"}},
+		{Tbinary, "roman", []string{"romulus", "rubens"}},
+		{Tpool, "", nil},
+		{Tfold, "ROMAN", []string{"romulus", "rubens"}},
+		{Treverse, "us", []string{"romane", "rubens"}},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(tc.flags)
+			for i, label := range []string{"romane", "romanus", "romulus", "rubens"} {
+				tr.Add(label, i)
+			}
+			var r recorder
+			tr.Observe(&r)
+			if want, got := 4-len(tc.want), tr.ExpirePrefix(tc.prefix); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			var dels int
+			for _, c := range r {
+				if strings.HasPrefix(c, "del ") {
+					dels++
+				}
+			}
+			if want, got := 4-len(tc.want), dels; want != got {
+				t.Errorf("want %d, got %d (%q)", want, got, r)
+			}
+			var keys []string
+			tr.Walk(func(key string, _ *Node) bool {
+				keys = append(keys, key)
+				return true
+			})
+			if want, got := tc.want, keys; !reflect.DeepEqual(want, got) {
+				t.Errorf("want %q, got %q", want, got)
+			}
+			if want, got := len(tc.want), tr.Count(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+		})
+	}
+
+	// Bit strings are also deleted.
+	tr := New(Tbinary)
+	tr.AddBits(nil, 0, 1)
+	tr.AddBits([]byte{0xA0}, 3, 2)
+	tr.AddBits([]byte{0xA0}, 4, 3)
+	tr.AddBits([]byte{0xA0, 0x80}, 9, 4)
+	if want, got := 4, tr.ExpirePrefix(""); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if want, got := 0, tr.Count(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+	if want, got := 1, tr.Len(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
+
+func TestJanitor(t *testing.T) {
+	if want, got := ErrUnsafe, New(0).StartJanitor(time.Millisecond); want != got {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	tr := New(Tsafe)
+	if want, got := ErrInterval, tr.StartJanitor(0); want != got {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if err := tr.StartJanitor(time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.StartJanitor(time.Millisecond); err != nil { // already running
+		t.Fatal(err)
+	}
+	tr.AddWithTTL("romane", 1, time.Millisecond)
+	tr.Add("romanus", 2)
+	// Count skips expired values right away, but only the janitor deletes their nodes.
+	deadline := time.Now().Add(time.Second)
+	for tr.Len() > 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	tr.Close()
+	tr.Close() // already stopped
+	if want, got := 2, tr.Len(); want != got {
+		t.Errorf("want %d, got %d", want, got)
+	}
+}
+
+func TestExpiredSetOperations(t *testing.T) {
+	testCases := []struct {
+		flags, otherFlags int
+	}{
+		{0, 0},
+		{Tbinary, Tbinary},
+		{0, Tfold},
+		{Tbinary, 0},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(tc.flags)
+			tr.Add("romane", 1)
+			tr.AddWithTTL("romulus", 2, time.Nanosecond)
+			other := New(tc.otherFlags)
+			other.AddWithTTL("romane", 3, time.Nanosecond)
+			other.AddWithTTL("romanus", 4, time.Nanosecond)
+			other.Add("romulus", 5)
+			other.AddWithTTL("rubens", 6, 20*time.Millisecond)
+			time.Sleep(time.Millisecond)
+
+			// Expired values of other are skipped and expired values of the tree are replaced.
+			merged := tr.Clone()
+			merged.Merge(other, nil)
+			for label, want := range map[string]interface{}{"romane": 1, "romanus": nil, "romulus": 5, "rubens": 6} {
+				var got interface{}
+				if n, _ := merged.Get(label); n != nil {
+					got = n.Value
+				}
+				if want != got {
+					t.Errorf("want %v for %q, got %v", want, label, got)
+				}
+			}
+			if want, got := 3, merged.Count(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+			if want, got := 0, merged.DelExpired(); want != got {
+				t.Errorf("want %d, got %d", want, got)
+			}
+
+			// Values merged with a TTL still expire.
+			time.Sleep(30 * time.Millisecond)
+			if n, _ := merged.Get("rubens"); n != nil {
+				t.Errorf("want %q to be expired, got %v", "rubens", n.Value)
+			}
+
+			intersected := New(tc.flags)
+			intersected.Add("romane", 1)
+			intersected.Add("romulus", 2)
+			intersected.Intersect(other, nil)
+			if n, _ := intersected.Get("romane"); n != nil {
+				t.Errorf("want %q to be removed, got %v", "romane", n.Value)
+			}
+			if n, _ := intersected.Get("romulus"); n == nil || n.Value != 2 {
+				t.Errorf("want %d, got %v", 2, n)
+			}
+
+			subtracted := New(tc.flags)
+			subtracted.Add("romane", 1)
+			subtracted.Add("romulus", 2)
+			subtracted.Difference(other)
+			if n, _ := subtracted.Get("romane"); n == nil || n.Value != 1 {
+				t.Errorf("want %d, got %v", 1, n)
+			}
+			if n, _ := subtracted.Get("romulus"); n != nil {
+				t.Errorf("want %q to be removed, got %v", "romulus", n.Value)
+			}
+		})
+	}
+}
+
+func TestExpiredHosts(t *testing.T) {
+	tr := New(Thost)
+	tr.Add("*.example.com", 1)
+	tr.Add("@sub.example.com", 2)
+	tr.AddWithTTL("api.example.com", 3, time.Nanosecond)
+	tr.AddWithTTL("www.example.com", 4, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	// Expired patterns fall back to less specific ones.
+	if n, _ := tr.Get("api.example.com"); n == nil || n.Value != 2 {
+		t.Errorf("want %d, got %v", 2, n)
+	}
+	tr.Del("@sub.example.com")
+	n, params := tr.Get("www.example.com")
+	if n == nil || n.Value != 1 {
+		t.Errorf("want %d, got %v", 1, n)
+	}
+	if len(params) != 0 {
+		t.Errorf("want no params, got %v", params)
+	}
+}
@@ -0,0 +1,134 @@
+package radix
+
+import (
+	"strings"
+	"sync"
+)
+
+// EventType is the kind of change reported by an Event.
+type EventType int
+
+const (
+	// AddEvent reports a label that was added.
+	AddEvent EventType = iota
+	// UpdateEvent reports a label whose value was replaced.
+	UpdateEvent
+	// DeleteEvent reports a label that was deleted.
+	DeleteEvent
+	// OverflowEvent reports that events were dropped because
+	// the receiver fell behind. It carries no key nor values.
+	OverflowEvent
+)
+
+// watchBuffer is the number of events buffered for each watcher.
+const watchBuffer = 256
+
+// Event is a change to a label of a tree.
+type Event struct {
+	Type EventType
+	Key  string      // label as it was added
+	Old  interface{} // nil for AddEvent
+	New  interface{} // nil for DeleteEvent
+}
+
+// watcher buffers events in a channel, so changing
+// the tree never blocks on slow receivers.
+type watcher struct {
+	prefix   string
+	ch       chan Event
+	overflow bool // whether events are being dropped
+}
+
+// Watch returns a channel that receives an event every time a label that starts
+// with prefix is added, replaced or deleted, and a function that stops watching.
+// Events are delivered in the order changes were made. After cancel is called,
+// the channel is closed, but events sent before that can still be received.
+//
+// Up to 256 events are buffered, so changing the tree never waits for receivers.
+// Once the buffer is full, an OverflowEvent is sent and further events are dropped
+// until there's room in the buffer again, so receivers know they missed changes.
+//
+// Prefixes are transformed just like labels are, so labels of reversed
+// and hostname trees that end with prefix are watched instead.
+// Changes made by Merge, Intersect and Difference are also reported.
+func (tr *Tree) Watch(prefix string) (<-chan Event, func()) {
+	w := &watcher{
+		prefix: tr.key(prefix),
+		ch:     make(chan Event, watchBuffer),
+	}
+	if tr.safe {
+		tr.mu.Lock()
+	}
+	tr.watchers = append(tr.watchers, w)
+	if tr.safe {
+		tr.mu.Unlock()
+	}
+	var once sync.Once
+	return w.ch, func() {
+		once.Do(func() {
+			tr.unwatch(w)
+			close(w.ch)
+		})
+	}
+}
+
+// unwatch removes w from the tree's watchers.
+func (tr *Tree) unwatch(w *watcher) {
+	if tr.safe {
+		defer tr.mu.Unlock()
+		tr.mu.Lock()
+	}
+	for i := range tr.watchers {
+		if tr.watchers[i] == w {
+			last := len(tr.watchers) - 1
+			copy(tr.watchers[i:], tr.watchers[i+1:])
+			tr.watchers[last] = nil
+			tr.watchers = tr.watchers[:last]
+			return
+		}
+	}
+}
+
+// notifyAdd notifies watchers and observers that v was added to the node of label,
+// which is already transformed, replacing old.
+func (tr *Tree) notifyAdd(label, key string, old, v interface{}) {
+	typ := AddEvent
+	if old != nil {
+		typ = UpdateEvent
+	}
+	tr.notify(label, Event{Type: typ, Key: key, Old: old, New: v})
+	for _, o := range tr.observers {
+		o.OnAdd(key, old, v)
+	}
+}
+
+// notifyDel notifies watchers and observers that old was deleted from the node of label,
+// which is already transformed.
+func (tr *Tree) notifyDel(label, key string, old interface{}) {
+	tr.notify(label, Event{Type: DeleteEvent, Key: key, Old: old})
+	for _, o := range tr.observers {
+		o.OnDel(key, old)
+	}
+}
+
+func (tr *Tree) notify(label string, e Event) {
+	for _, w := range tr.watchers {
+		if strings.HasPrefix(label, w.prefix) {
+			w.push(e)
+		}
+	}
+}
+
+// push sends e without blocking. The last slot of the buffer is kept for reporting
+// an overflow, which is always possible, since events are only sent while the tree is locked.
+func (w *watcher) push(e Event) {
+	if len(w.ch) < cap(w.ch)-1 {
+		w.overflow = false
+		w.ch <- e
+		return
+	}
+	if !w.overflow {
+		w.overflow = true
+		w.ch <- Event{Type: OverflowEvent}
+	}
+}
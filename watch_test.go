@@ -0,0 +1,133 @@
+package radix_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/gbrlsnchs/radix"
+)
+
+func TestWatch(t *testing.T) {
+	testCases := []struct {
+		flags  int
+		prefix string
+		key    string // watched
+		other  string // not watched
+	}{
+		{0, "rom", "romane", "rubens"},
+		{Tsafe, "rom", "romane", "rubens"},
+		{Tbinary, "rom", "romane", "rubens"},
+		{Tpool, "rom", "romane", "rubens"},
+		{Tfold, "ROM", "Romane", "rubens"},
+		{Treverse, "ne", "romane", "rubens"},
+		{Thost, "example.com", "api.example.com", "example.org"},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			tr := New(tc.flags)
+			events, cancel := tr.Watch(tc.prefix)
+			key := tc.key
+			tr.Add(key, 1)
+			tr.Add(key, 2)
+			tr.Add(tc.other, 3)
+			tr.Del(tc.other)
+			tr.Del(key + "s") // not in the tree
+			tr.Del(key)
+			want := []Event{
+				{Type: AddEvent, Key: key, New: 1},
+				{Type: UpdateEvent, Key: key, Old: 1, New: 2},
+				{Type: DeleteEvent, Key: key, Old: 2},
+			}
+			for _, w := range want {
+				if got := receive(t, events); !reflect.DeepEqual(w, got) {
+					t.Errorf("want %+v, got %+v", w, got)
+				}
+			}
+			cancel()
+			cancel() // canceling twice is a no-op
+			tr.Add(key, 4)
+			if e, ok := <-events; ok {
+				t.Errorf("want channel to be closed, got %+v", e)
+			}
+		})
+	}
+
+	// Bits and batches are also watched.
+	tr := New(Tbinary)
+	events, cancel := tr.Watch("")
+	defer cancel()
+	bits, cancelBits := tr.Watch("\xa0")
+	defer cancelBits()
+	tr.AddBits([]byte{0xAF}, 3, 1)
+	tr.DelBits([]byte{0xAF}, 3) // bits past nbits are ignored
+	tr.AddAll(MapIterator(map[string]interface{}{"romane": 2}), true)
+	tr.DelAll([]string{"romane"})
+	for _, w := range []Event{
+		{Type: AddEvent, Key: "\xa0", New: 1},
+		{Type: DeleteEvent, Key: "\xa0", Old: 1},
+		{Type: AddEvent, Key: "romane", New: 2},
+		{Type: DeleteEvent, Key: "romane", Old: 2},
+	} {
+		if got := receive(t, events); !reflect.DeepEqual(w, got) {
+			t.Errorf("want %+v, got %+v", w, got)
+		}
+	}
+	for _, typ := range []EventType{AddEvent, DeleteEvent} {
+		if got := receive(t, bits); got.Type != typ {
+			t.Errorf("want %v, got %+v", typ, got)
+		}
+	}
+}
+
+func TestWatchConcurrent(t *testing.T) {
+	tr := New(Tsafe)
+	events, cancel := tr.Watch("")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			tr.Add("romane", i+1)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if e := receive(t, events); e.New != i+1 {
+			t.Fatalf("want %d, got %v", i+1, e.New)
+		}
+	}
+	<-done
+	cancel()
+}
+
+func receive(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("want event, got none")
+	}
+	return Event{}
+}
+
+func TestWatchOverflow(t *testing.T) {
+	tr := New(0)
+	events, cancel := tr.Watch("")
+	defer cancel()
+	for i := 0; i < 300; i++ {
+		tr.Add("romane", i)
+	}
+	for i := 0; i < 255; i++ {
+		if e := receive(t, events); e.New != i {
+			t.Fatalf("want %d, got %+v", i, e)
+		}
+	}
+	if want, got := (Event{Type: OverflowEvent}), receive(t, events); want != got {
+		t.Fatalf("want %+v, got %+v", want, got)
+	}
+	// Events are sent again once there's room for them.
+	tr.Del("romane")
+	if e := receive(t, events); e.Type != DeleteEvent || e.Old != 299 {
+		t.Errorf("want %q to be deleted, got %+v", "romane", e)
+	}
+}